@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iammatthew2/zoink/pkg/zoink"
+	"github.com/spf13/cobra"
+)
+
+// forgetChoiceCmd represents the forget-choice command
+var forgetChoiceCmd = &cobra.Command{
+	Use:   "forget-choice [query]",
+	Short: "Forget directories picked interactively for a query",
+	Long: `Directories picked from interactive results (z -i) rank higher for the
+same and similar queries afterwards. This forgets those picks for a query,
+or all of them with --all.
+
+Examples:
+  zoink forget-choice proj       Forget picks made for 'proj'
+  zoink forget-choice --all      Forget every pick`,
+	Args: cobra.ArbitraryArgs,
+	Run:  handleForgetChoiceCommand,
+}
+
+// recordChoiceCmd is used by the shell integration to report fzf selections
+var recordChoiceCmd = &cobra.Command{
+	Use:    "record-choice <query> <directory>",
+	Short:  "Record an interactive selection (used by shell integration)",
+	Args:   cobra.ExactArgs(2),
+	Hidden: true,
+	Run:    handleRecordChoiceCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(forgetChoiceCmd)
+	rootCmd.AddCommand(recordChoiceCmd)
+
+	forgetChoiceCmd.Flags().Bool("all", false, "Forget the picks for every query")
+}
+
+// handleForgetChoiceCommand forgets recorded interactive picks
+func handleForgetChoiceCommand(cmd *cobra.Command, args []string) {
+	all, _ := cmd.Flags().GetBool("all")
+	query := strings.Join(args, " ")
+
+	if query == "" && !all {
+		fmt.Fprintf(os.Stderr, "Error: give a query or use --all\n")
+		os.Exit(1)
+	}
+	if query != "" && all {
+		fmt.Fprintf(os.Stderr, "Error: --all can't be combined with a query\n")
+		os.Exit(1)
+	}
+
+	// Get database config
+	cfg := GetConfig()
+
+	// Check if database exists
+	if _, err := os.Stat(cfg.DatabasePath); os.IsNotExist(err) {
+		fmt.Println("Database does not exist yet")
+		return
+	}
+
+	// Open database
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer db.Close()
+
+	forgotten, err := db.ForgetChoices(query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	// Save database
+	if err := db.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	fmt.Printf("Forgot %d picks\n", forgotten)
+}
+
+// handleRecordChoiceCommand records a directory picked for a query
+func handleRecordChoiceCommand(cmd *cobra.Command, args []string) {
+	absDir, err := filepath.Abs(args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving path '%s': %v\n", args[1], err)
+		os.Exit(exitCode(err))
+	}
+
+	// Get database config
+	cfg := GetConfig()
+
+	// Open database
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer db.Close()
+
+	if err := db.RecordChoice(args[0], absDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	// Save database
+	if err := db.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+}
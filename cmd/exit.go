@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/iammatthew2/zoink/pkg/zoink"
+)
+
+// Exit codes. They are documented in the README and the shell hooks rely on
+// them, so they must not change.
+const (
+	// exitError is any failure without a more specific code
+	exitError = 1
+	// exitNotFound means nothing matched or the directory isn't tracked
+	exitNotFound = 2
+	// exitCorrupt means the database file can't be read
+	exitCorrupt = 3
+	// exitVersionMismatch means the database was written by a newer zoink
+	exitVersionMismatch = 4
+	// exitLocked means another process held the database lock too long
+	exitLocked = 5
+)
+
+// exitCode returns the exit code for an error
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, zoink.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, zoink.ErrCorrupt):
+		return exitCorrupt
+	case errors.Is(err, zoink.ErrVersionMismatch):
+		return exitVersionMismatch
+	case errors.Is(err, zoink.ErrLocked):
+		return exitLocked
+	default:
+		return exitError
+	}
+}
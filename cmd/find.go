@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -15,10 +17,22 @@ var findCmd = &cobra.Command{
 This command is used by the shell alias (z) for directory navigation.
 It provides the core directory matching and selection functionality.
 
+Query terms are separated by spaces and must all match the directory name:
+  foo      fuzzy match          'foo     exact substring
+  ^foo     starts with foo      foo$     ends with foo
+  !foo     must not contain foo a | b    either term (also a|b)
+  #tag     tagged with tag
+Terms are case-insensitive unless they contain a capital letter. Word
+initials match too: 'gcp' finds google-cloud-platform, 'ma' finds MyApp.
+Quote terms with '!' or '#' in bash, where they have special meaning.
+
 Examples (via shell alias):
   z foo                  Navigate to best project match
   z -i foo               Interactive selection for foo-related documents
-  z -l foo               List foo-related directories`,
+  z -l foo               List foo-related directories
+  z '^api !test'         Names starting with api, without test
+  zoink find --regex '^/srv/.*/current$' --list
+  zoink find --glob "$HOME/code/**/api" --json`,
 	Args: cobra.ArbitraryArgs,
 	Run:  executeFind,
 }
@@ -32,6 +46,13 @@ func init() {
 	findCmd.Flags().BoolP("echo", "e", false, "Echo path only (for shell integration)")
 	findCmd.Flags().BoolP("recent", "t", false, "Prefer recent directories")
 	findCmd.Flags().BoolP("frequent", "f", false, "Prefer frequently used directories")
+	findCmd.Flags().Bool("include-offline", false, "Include directories on volumes that are not mounted")
+	findCmd.Flags().Bool("notes", false, "Let query words that don't match a directory match its note")
+	findCmd.Flags().Bool("with-notes", false, "Append notes to --echo output, separated by a tab")
+	findCmd.Flags().Bool("time-aware", false, "Prefer directories usually visited at this time of the week")
+	findCmd.Flags().String("regex", "", "Match the full path against an RE2 expression instead of a query")
+	findCmd.Flags().String("glob", "", "Match the full path against a glob ('**' crosses directories) instead of a query")
+	findCmd.Flags().Bool("json", false, "Print matches as JSON")
 }
 
 // executeFind is the main command handler for the find command
@@ -46,8 +67,18 @@ func executeFind(cmd *cobra.Command, args []string) {
 	query := strings.Join(args, " ")
 	config := buildConfigFromFlags(cmd)
 
+	if (config.Regex != "" || config.Glob != "") && query != "" {
+		fmt.Fprintf(os.Stderr, "Error: --regex and --glob can't be combined with a query\n")
+		os.Exit(1)
+	}
+	if config.Regex != "" && config.Glob != "" {
+		fmt.Fprintf(os.Stderr, "Error: --regex and --glob can't be combined\n")
+		os.Exit(1)
+	}
+
 	// Handle empty query - return most frecent directory for shell integration
-	if query == "" && !config.Interactive && !config.ListOnly {
+	if query == "" && !config.Interactive && !config.ListOnly && !config.JSON &&
+		config.Regex == "" && config.Glob == "" {
 		handleEmptyQuery()
 		return
 	}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iammatthew2/zoink/internal/history"
+	"github.com/iammatthew2/zoink/pkg/zoink"
+	"github.com/spf13/cobra"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import --from-history [file]",
+	Short: "Seed the database from shell history",
+	Long: `Import directories from existing data into the zoink database.
+
+With --from-history, cd/pushd commands from a bash, zsh or fish history file
+are replayed to work out which directories were visited and when. Relative
+targets are resolved by simulating the working directory where possible,
+and only directories that still exist are imported. Importing the same file
+again only adds the commands appended since.
+
+When no file is given, $HISTFILE is used, falling back to the default
+history file of the current shell.
+
+Examples:
+  zoink import --from-history                          Import current shell history
+  zoink import --from-history ~/.zsh_history           Import a specific file
+  zoink import --from-history --shell fish hist.txt    Force the file format
+  zoink import --from-history --dry-run                Show what would be imported`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  handleImportCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().Bool("from-history", false, "Import cd commands from a shell history file")
+	importCmd.Flags().String("shell", "", "History file format: bash, zsh or fish (default: detect)")
+	importCmd.Flags().BoolP("dry-run", "n", false, "Show directories without importing them")
+}
+
+// handleImportCommand imports visits from shell history
+func handleImportCommand(cmd *cobra.Command, args []string) {
+	fromHistory, _ := cmd.Flags().GetBool("from-history")
+	shellName, _ := cmd.Flags().GetString("shell")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if !fromHistory {
+		fmt.Fprintf(os.Stderr, "Error: nothing to import (use --from-history)\n")
+		os.Exit(1)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting home directory: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	// Work out which file to read
+	historyFile := ""
+	if len(args) > 0 {
+		historyFile = args[0]
+	} else if histFile := os.Getenv("HISTFILE"); histFile != "" {
+		historyFile = histFile
+	} else {
+		name := shellName
+		if name == "" {
+			name = filepath.Base(os.Getenv("SHELL"))
+		}
+		historyFile = history.DefaultFile(name, home)
+	}
+
+	format := history.Format(shellName)
+	if format == "" {
+		format, err = history.DetectFormat(historyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading history file: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+	}
+
+	file, err := os.Open(historyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading history file: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer file.Close()
+
+	commands, err := history.Parse(file, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s history: %v\n", format, err)
+		os.Exit(exitCode(err))
+	}
+
+	// Open database
+	cfg := GetConfig()
+	open := zoink.Open
+	if dryRun {
+		open = zoink.OpenReadOnly
+	}
+	db, err := open(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	// Imports are recorded per file, so importing the same history again
+	// only adds the commands appended since
+	source, err := filepath.Abs(historyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving path '%s': %v\n", historyFile, err)
+		os.Exit(exitCode(err))
+	}
+
+	// Visits without a recorded time are dated to the last history write
+	fallbackTime := int64(0)
+	if info, err := file.Stat(); err == nil {
+		fallbackTime = info.ModTime().Unix()
+	}
+
+	// pending returns the visits not imported yet to directories that still
+	// exist, how many were skipped as missing, and the marker to save
+	pending := func(tx *zoink.Tx) ([]history.Visit, int, zoink.ImportMarker) {
+		marker, _ := tx.ImportMarker(source)
+		visits, offset, fingerprint := history.Pending(commands, home, marker.Commands, marker.Fingerprint)
+
+		var existing []history.Visit
+		missing := 0
+		for _, visit := range visits {
+			if info, err := os.Stat(visit.Path); err != nil || !info.IsDir() {
+				missing++
+				continue
+			}
+			if visit.Timestamp == 0 {
+				visit.Timestamp = fallbackTime
+			}
+			existing = append(existing, visit)
+		}
+		return existing, missing, zoink.ImportMarker{Commands: offset, Fingerprint: fingerprint}
+	}
+
+	if dryRun {
+		var visits []history.Visit
+		var missing int
+		db.View(func(tx *zoink.Tx) error {
+			visits, missing, _ = pending(tx)
+			return nil
+		})
+		if len(visits) == 0 {
+			fmt.Printf("No new visits to existing directories in %s (%d commands read, %d in missing directories)\n",
+				historyFile, len(commands), missing)
+			return
+		}
+
+		counts := make(map[string]int)
+		var order []string
+		for _, visit := range visits {
+			if counts[visit.Path] == 0 {
+				order = append(order, visit.Path)
+			}
+			counts[visit.Path]++
+		}
+		fmt.Printf("Would import %d visits to %d directories (dry run):\n", len(visits), len(order))
+		for _, path := range order {
+			fmt.Printf("  %s (%d visits)\n", path, counts[path])
+		}
+		return
+	}
+
+	var imported, missing int
+	err = db.Update(func(tx *zoink.Tx) error {
+		visits, skipped, marker := pending(tx)
+		for _, visit := range visits {
+			if err := tx.AddAt(visit.Path, time.Unix(visit.Timestamp, 0)); err != nil {
+				return fmt.Errorf("adding visit to %s: %w", visit.Path, err)
+			}
+		}
+		imported, missing = len(visits), skipped
+		return tx.SetImportMarker(source, marker)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error importing visits: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	if imported == 0 {
+		fmt.Printf("No new visits to existing directories in %s (%d commands read, %d in missing directories)\n",
+			historyFile, len(commands), missing)
+		return
+	}
+	fmt.Printf("Imported %d visits from %s (%s format); skipped %d in missing directories\n",
+		imported, historyFile, format, missing)
+}
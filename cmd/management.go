@@ -1,14 +1,19 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
-	"github.com/iammatthew2/zoink/internal/database"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/iammatthew2/zoink/internal/scan"
+	"github.com/iammatthew2/zoink/pkg/zoink"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 // statsCmd represents the stats command
@@ -25,9 +30,63 @@ var statsCmd = &cobra.Command{
 var cleanCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "Remove non-existent directories",
-	Long:  `Clean up the database by removing directories that no longer exist.`,
+	Long: `Clean up the database by removing directories that no longer exist.
+
+In a terminal you confirm which directories to remove; the removal can be
+reverted with 'zoink undo'. Directories on volumes that are not mounted are
+kept and marked offline.
+
+With --relocate, each missing directory is first looked up by name under the
+configured search roots ("search_roots" in the config file, default: your
+home directory). When a same-named directory is found you are offered to
+remap the entry, and everything below it, instead of deleting it.
+
+Examples:
+  zoink clean                    Choose which missing directories to remove
+  zoink clean --dry-run          Only show what would be removed
+  zoink clean --yes              Remove without asking
+  zoink clean --relocate         Offer to remap moved directories first`,
 	Run: func(cmd *cobra.Command, args []string) {
-		handleClean()
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		relocate, _ := cmd.Flags().GetBool("relocate")
+		assumeYes, _ := cmd.Flags().GetBool("yes")
+		handleClean(cleanOptions{
+			DryRun:    dryRun,
+			Relocate:  relocate,
+			AssumeYes: assumeYes,
+		})
+	},
+}
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the last destructive operation",
+	Long: `Restore the entries changed by the last clean, remove, prune or mv.
+
+Entries are restored with their original visit counts and timestamps.
+Running undo repeatedly steps further back through the trash journal.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		handleUndo()
+	},
+}
+
+// mvCmd represents the mv command
+var mvCmd = &cobra.Command{
+	Use:   "mv <old-prefix> <new-prefix>",
+	Short: "Rewrite directory paths after a move",
+	Long: `Rewrite every entry at or below old-prefix to live under new-prefix.
+
+Use this after reorganizing directories so their history is kept. When a
+rewritten path is already tracked, the two entries are merged.
+
+Examples:
+  zoink mv ~/code ~/work               Move a whole tree
+  zoink mv ~/work/old-name ~/work/new  Rename a single project`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		handleMove(args[0], args[1])
 	},
 }
 
@@ -43,7 +102,7 @@ var addCmd = &cobra.Command{
 			currentDir, err := os.Getwd()
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
-				os.Exit(1)
+				os.Exit(exitCode(err))
 			}
 			handleAdd(currentDir)
 		} else if len(args) == 1 {
@@ -70,13 +129,25 @@ func init() {
 	rootCmd.AddCommand(cleanCmd)
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(removeCmd)
+	rootCmd.AddCommand(mvCmd)
+	rootCmd.AddCommand(undoCmd)
+
+	cleanCmd.Flags().BoolP("dry-run", "n", false, "Show what would be removed without changing anything")
+	cleanCmd.Flags().Bool("relocate", false, "Offer to remap missing directories found elsewhere")
+	cleanCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation (only unambiguous relocations are made)")
+}
+
+// cleanOptions holds the flags of the clean command
+type cleanOptions struct {
+	DryRun    bool
+	Relocate  bool
+	AssumeYes bool
 }
 
 // handleStats displays usage statistics
 func handleStats() {
 	// Get database config
 	cfg := GetConfig()
-	dbConfig := database.DatabaseConfig{Path: cfg.DatabasePath}
 
 	// Check if database exists
 	if _, err := os.Stat(cfg.DatabasePath); os.IsNotExist(err) {
@@ -85,20 +156,19 @@ func handleStats() {
 		return
 	}
 
-	// Open database
-	db, err := database.New(dbConfig)
+	// Open database (stats only reads it)
+	db, err := zoink.OpenReadOnly(cfg.DatabasePath, newOpenOptions(cfg))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
-	defer db.Close()
 
-	// Get all entries
-	entries, err := db.GetAll()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting entries: %v\n", err)
-		os.Exit(1)
-	}
+	// Read a snapshot of the entries
+	var entries []zoink.Entry
+	db.View(func(tx *zoink.Tx) error {
+		entries = tx.All()
+		return nil
+	})
 
 	if len(entries) == 0 {
 		fmt.Println("Database is empty")
@@ -106,24 +176,25 @@ func handleStats() {
 	}
 
 	// Calculate statistics
-	var totalVisits uint32 = 0
-	var maxVisits uint32 = 0
-	var oldestEntry, newestEntry *database.DirectoryEntry
+	totalVisits := 0
+	maxVisits := 0
+	var oldestEntry, newestEntry *zoink.Entry
+	offlineCount := 0
 
 	for i, entry := range entries {
-		totalVisits += entry.VisitCount
-		if entry.VisitCount > maxVisits {
-			maxVisits = entry.VisitCount
+		if entry.Offline {
+			offlineCount++
+		}
+		totalVisits += entry.Visits
+		if entry.Visits > maxVisits {
+			maxVisits = entry.Visits
 		}
 
-		firstVisited := time.Unix(entry.FirstVisited, 0)
-		lastVisited := time.Unix(entry.LastVisited, 0)
-
-		if oldestEntry == nil || firstVisited.Before(time.Unix(oldestEntry.FirstVisited, 0)) {
-			oldestEntry = entries[i]
+		if oldestEntry == nil || entry.FirstVisited.Before(oldestEntry.FirstVisited) {
+			oldestEntry = &entries[i]
 		}
-		if newestEntry == nil || lastVisited.After(time.Unix(newestEntry.LastVisited, 0)) {
-			newestEntry = entries[i]
+		if newestEntry == nil || entry.LastVisited.After(newestEntry.LastVisited) {
+			newestEntry = &entries[i]
 		}
 	}
 
@@ -135,6 +206,28 @@ func handleStats() {
 	fmt.Println()
 	fmt.Printf("Database location: %s\n", cfg.DatabasePath)
 	fmt.Printf("Total entries: %d\n", len(entries))
+	if offlineCount > 0 {
+		fmt.Printf("Offline entries: %d (volume not mounted)\n", offlineCount)
+	}
+	if evicted := db.EvictedCount(); cfg.MaxEntries > 0 || evicted > 0 {
+		limit := "unlimited"
+		if cfg.MaxEntries > 0 {
+			limit = fmt.Sprintf("%d", cfg.MaxEntries)
+		}
+		fmt.Printf("Evicted entries: %d (max_entries: %s)\n", evicted, limit)
+	}
+	if tagCounts := db.TagCounts(); len(tagCounts) > 0 {
+		tags := make([]string, 0, len(tagCounts))
+		for tag := range tagCounts {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		var parts []string
+		for _, tag := range tags {
+			parts = append(parts, fmt.Sprintf("#%s (%d)", tag, tagCounts[tag]))
+		}
+		fmt.Printf("Tags: %s\n", strings.Join(parts, ", "))
+	}
 	fmt.Printf("Total visits: %d\n", totalVisits)
 	fmt.Printf("Average visits per directory: %.1f\n", avgVisits)
 	fmt.Printf("Most visited directory: %d visits\n", maxVisits)
@@ -142,17 +235,23 @@ func handleStats() {
 	if oldestEntry != nil {
 		fmt.Printf("Oldest entry: %s (%s)\n",
 			filepath.Base(oldestEntry.Path),
-			time.Unix(oldestEntry.FirstVisited, 0).Format("2006-01-02"))
+			oldestEntry.FirstVisited.Format("2006-01-02"))
 	}
 	if newestEntry != nil {
 		fmt.Printf("Most recent visit: %s (%s)\n",
 			filepath.Base(newestEntry.Path),
-			time.Unix(newestEntry.LastVisited, 0).Format("2006-01-02 15:04"))
+			newestEntry.LastVisited.Format("2006-01-02 15:04"))
 	}
 
 	// Show top 5 most visited
 	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].VisitCount > entries[j].VisitCount
+		if entries[i].Visits != entries[j].Visits {
+			return entries[i].Visits > entries[j].Visits
+		}
+		if !entries[i].LastVisited.Equal(entries[j].LastVisited) {
+			return entries[i].LastVisited.After(entries[j].LastVisited)
+		}
+		return entries[i].Path < entries[j].Path
 	})
 
 	fmt.Println("\nTop 5 Most Visited:")
@@ -163,21 +262,23 @@ func handleStats() {
 
 	for i := 0; i < limit; i++ {
 		entry := entries[i]
-		lastVisited := time.Unix(entry.LastVisited, 0)
 		lastVisit := "just now"
-		if time.Since(lastVisited) > time.Minute {
-			lastVisit = lastVisited.Format("Jan 2")
+		if time.Since(entry.LastVisited) > time.Minute {
+			lastVisit = entry.LastVisited.Format("Jan 2")
+		}
+		tags := ""
+		if len(entry.Tags) > 0 {
+			tags = " #" + strings.Join(entry.Tags, " #")
 		}
-		fmt.Printf("  %d. %s (%d visits, last: %s)\n",
-			i+1, entry.Path, entry.VisitCount, lastVisit)
+		fmt.Printf("  %d. %s (%d visits, last: %s)%s\n",
+			i+1, entry.Path, entry.Visits, lastVisit, tags)
 	}
 }
 
 // handleClean removes non-existent directories from database
-func handleClean() {
+func handleClean(opts cleanOptions) {
 	// Get database config
 	cfg := GetConfig()
-	dbConfig := database.DatabaseConfig{Path: cfg.DatabasePath}
 
 	// Check if database exists
 	if _, err := os.Stat(cfg.DatabasePath); os.IsNotExist(err) {
@@ -186,56 +287,292 @@ func handleClean() {
 	}
 
 	// Open database
-	db, err := database.New(dbConfig)
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
-	defer db.Close()
 
-	// Get all entries
-	entries, err := db.GetAll()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting entries: %v\n", err)
-		os.Exit(1)
-	}
+	// Read a snapshot of the entries
+	var entries []zoink.Entry
+	db.View(func(tx *zoink.Tx) error {
+		entries = tx.All()
+		return nil
+	})
 
 	if len(entries) == 0 {
 		fmt.Println("Database is empty - nothing to clean")
 		return
 	}
 
-	// Check which directories no longer exist
-	var toRemove []string
-	for _, entry := range entries {
-		if _, err := os.Stat(entry.Path); os.IsNotExist(err) {
-			toRemove = append(toRemove, entry.Path)
+	// Check which directories no longer exist, keeping those on unmounted volumes
+	toRemove, offline := findMissing(db, entries)
+	if len(offline) > 0 {
+		fmt.Printf("Keeping %d directories on volumes that are not mounted:\n", len(offline))
+		for _, path := range offline {
+			fmt.Printf("  ? %s\n", path)
 		}
 	}
 
+	if opts.DryRun {
+		printCleanPreview(toRemove, opts.Relocate)
+		return
+	}
+
+	// Decide what to remap and remove before changing anything, so the
+	// prompts don't hold the database lock
+	anyMissing := len(toRemove) > 0
+	var relocations []relocation
+	if opts.Relocate && anyMissing {
+		relocations = chooseRelocations(toRemove, opts.AssumeYes)
+		toRemove = withoutRelocated(toRemove, relocations)
+	}
+
+	// Let the user pick which directories to drop
+	if !opts.AssumeYes && len(toRemove) > 0 && term.IsTerminal(int(os.Stdin.Fd())) {
+		toRemove = confirmRemoval(toRemove)
+	}
+
+	// Apply everything at once: either all of it is saved or nothing
+	counts := make([][2]int, len(relocations))
+	apply := func(tx *zoink.Tx) error {
+		if err := markOffline(tx, offline); err != nil {
+			return err
+		}
+		for i, r := range relocations {
+			moved, merged, err := tx.Move(r.from, r.to)
+			if err != nil {
+				return fmt.Errorf("remapping %s: %w", r.from, err)
+			}
+			counts[i] = [2]int{moved, merged}
+		}
+		for _, path := range toRemove {
+			if _, err := tx.Remove(path); err != nil {
+				return fmt.Errorf("removing %s: %w", path, err)
+			}
+		}
+		return nil
+	}
+	if anyMissing {
+		err = db.UpdateUndoable("clean", apply)
+	} else {
+		err = db.Update(apply)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error cleaning database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	for i, r := range relocations {
+		fmt.Printf("  ~ %s -> %s (%d entries, %d merged)\n", r.from, r.to, counts[i][0], counts[i][1])
+	}
+
+	if !anyMissing {
+		fmt.Printf("All %d directories still exist - nothing to clean\n", len(entries)-len(offline))
+		return
+	}
 	if len(toRemove) == 0 {
-		fmt.Printf("All %d directories still exist - nothing to clean\n", len(entries))
+		fmt.Println("Nothing removed")
 		return
 	}
 
-	// Remove non-existent directories
 	fmt.Printf("Cleaning %d non-existent directories:\n", len(toRemove))
 	for _, path := range toRemove {
 		fmt.Printf("  - %s\n", path)
-		if err := db.RemoveDirectory(path); err != nil {
-			fmt.Fprintf(os.Stderr, "Error removing %s: %v\n", path, err)
+	}
+	fmt.Printf("Cleaned %d entries. %d directories remain. Run 'zoink undo' to restore them.\n",
+		len(toRemove), len(entries)-len(toRemove))
+}
+
+// findMissing returns the sorted paths of entries that no longer exist and
+// of entries on volumes that aren't mounted
+func findMissing(db *zoink.DB, entries []zoink.Entry) ([]string, []string) {
+	var missing, offline []string
+	for _, entry := range entries {
+		switch db.CheckPath(entry.Path) {
+		case zoink.PathMissing:
+			missing = append(missing, entry.Path)
+		case zoink.PathOffline:
+			offline = append(offline, entry.Path)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(offline)
+	return missing, offline
+}
+
+// markOffline updates the offline mark of every entry to match the given
+// list of entries on unmounted volumes
+func markOffline(tx *zoink.Tx, offline []string) error {
+	isOffline := make(map[string]bool, len(offline))
+	for _, path := range offline {
+		isOffline[path] = true
+	}
+
+	changed := tx.Filter(zoink.FilterFunc(func(entry zoink.Entry) bool {
+		return entry.Offline != isOffline[entry.Path]
+	}))
+	for _, entry := range changed {
+		if err := tx.SetOffline(entry.Path, isOffline[entry.Path]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printCleanPreview shows what clean would do without changing anything
+func printCleanPreview(missing []string, relocate bool) {
+	if len(missing) == 0 {
+		fmt.Println("All directories still exist - nothing to clean")
+		return
+	}
+
+	var candidates map[string][]string
+	if relocate {
+		candidates = findRelocationCandidates(missing)
+	}
+
+	fmt.Printf("Would clean %d non-existent directories (dry run):\n", len(missing))
+	for _, path := range missing {
+		fmt.Printf("  - %s\n", path)
+		for _, candidate := range candidates[filepath.Base(path)] {
+			fmt.Printf("      possibly moved to %s\n", candidate)
+		}
+	}
+}
+
+// confirmRemoval asks which of the missing directories to remove, all
+// selected by default. Returns nothing if the prompt is cancelled.
+func confirmRemoval(missing []string) []string {
+	var selected []string
+	prompt := &survey.MultiSelect{
+		Message: "Remove these missing directories from the database?",
+		Options: missing,
+		Default: missing,
+	}
+
+	if err := survey.AskOne(prompt, &selected); err != nil {
+		return nil
+	}
+
+	return selected
+}
+
+// findRelocationCandidates looks up same-named directories for missing
+// paths under the configured search roots, keyed by basename
+func findRelocationCandidates(missing []string) map[string][]string {
+	cfg := GetConfig()
+
+	roots := cfg.SearchRoots
+	if len(roots) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting home directory: %v\n", err)
+			return nil
+		}
+		roots = []string{home}
+	}
+
+	var names []string
+	for _, path := range missing {
+		names = append(names, filepath.Base(path))
+	}
+
+	candidates, err := scan.FindNamed(roots, names, scan.Options{Exclude: cfg.ExcludePatterns})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error searching for moved directories: %v\n", err)
+		return nil
+	}
+
+	return candidates
+}
+
+// relocation remaps a missing directory to where it was found
+type relocation struct {
+	from, to string
+}
+
+// chooseRelocations looks for same-named directories under the configured
+// search roots and picks where each missing entry moved, asking unless
+// assumeYes is set
+func chooseRelocations(missing []string, assumeYes bool) []relocation {
+	candidates := findRelocationCandidates(missing)
+
+	var relocations []relocation
+	moved := make(map[string]string)
+	for _, path := range missing {
+		// Skip entries already carried along by an earlier parent remap
+		if isBelowAny(path, moved) {
+			continue
+		}
+
+		found := candidates[filepath.Base(path)]
+		if len(found) == 0 {
+			continue
+		}
+
+		target := ""
+		if assumeYes {
+			if len(found) == 1 {
+				target = found[0]
+			}
+		} else {
+			target = promptRelocation(path, found)
+		}
+		if target == "" {
 			continue
 		}
+
+		moved[path] = target
+		relocations = append(relocations, relocation{from: path, to: target})
 	}
 
-	// Save database
-	if err := db.Save(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error saving database: %v\n", err)
-		os.Exit(1)
+	return relocations
+}
+
+// withoutRelocated drops the paths carried along by relocations
+func withoutRelocated(missing []string, relocations []relocation) []string {
+	moved := make(map[string]string, len(relocations))
+	for _, r := range relocations {
+		moved[r.from] = r.to
 	}
 
-	fmt.Printf("Cleaned %d entries. %d directories remain.\n",
-		len(toRemove), len(entries)-len(toRemove))
+	var remaining []string
+	for _, path := range missing {
+		if _, exists := moved[path]; !exists && !isBelowAny(path, moved) {
+			remaining = append(remaining, path)
+		}
+	}
+	return remaining
+}
+
+// promptRelocation asks which candidate a missing directory moved to.
+// Returns an empty string when the user skips it.
+func promptRelocation(path string, candidates []string) string {
+	const skip = "Skip (remove from database)"
+
+	options := append(append([]string{}, candidates...), skip)
+	var selected string
+	prompt := &survey.Select{
+		Message: fmt.Sprintf("%s is missing. Remap to:", path),
+		Options: options,
+	}
+
+	if err := survey.AskOne(prompt, &selected); err != nil || selected == skip {
+		return ""
+	}
+
+	return selected
+}
+
+// isBelowAny reports whether path lies below one of the already moved prefixes
+func isBelowAny(path string, moved map[string]string) bool {
+	for prefix := range moved {
+		if strings.HasPrefix(path, prefix+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
 }
 
 // handleAdd manually adds a directory to the database
@@ -244,7 +581,7 @@ func handleAdd(dir string) {
 	absDir, err := filepath.Abs(dir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error resolving path '%s': %v\n", dir, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 
 	// Check if directory exists
@@ -253,33 +590,15 @@ func handleAdd(dir string) {
 		os.Exit(1)
 	}
 
-	// Get database config
-	cfg := GetConfig()
-	dbConfig := database.DatabaseConfig{Path: cfg.DatabasePath}
-
-	// Open database
-	db, err := database.New(dbConfig)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
-		os.Exit(1)
-	}
-	defer db.Close()
-
-	// Add visit
-	if err := db.AddVisit(absDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Error adding visit: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Save database
-	if err := db.Save(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error saving database: %v\n", err)
-		os.Exit(1)
-	}
+	queued := recordVisit(absDir, "")
 
 	// Only print success in verbose mode to avoid cluttering shell output
 	if verbose, _ := rootCmd.PersistentFlags().GetBool("verbose"); verbose {
-		fmt.Printf("Added visit to: %s\n", absDir)
+		if queued {
+			fmt.Printf("Database is locked, queued visit to: %s\n", absDir)
+		} else {
+			fmt.Printf("Added visit to: %s\n", absDir)
+		}
 	}
 }
 
@@ -289,7 +608,7 @@ func handleAddWithPrevious(dir string, previousDir string) {
 	absDir, err := filepath.Abs(dir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error resolving path '%s': %v\n", dir, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 
 	// Check if directory exists
@@ -298,34 +617,54 @@ func handleAddWithPrevious(dir string, previousDir string) {
 		os.Exit(1)
 	}
 
-	// Get database config
-	cfg := GetConfig()
-	dbConfig := database.DatabaseConfig{Path: cfg.DatabasePath}
-
-	// Open database
-	db, err := database.New(dbConfig)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
-		os.Exit(1)
+	// Add visit with previous directory
+	if absPrevious, err := filepath.Abs(previousDir); err == nil {
+		previousDir = absPrevious
 	}
-	defer db.Close()
+	queued := recordVisit(absDir, previousDir)
 
-	// Add visit with previous directory
-	if err := db.AddVisit(absDir, previousDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Error adding visit: %v\n", err)
-		os.Exit(1)
+	// Only print success in verbose mode to avoid cluttering shell output
+	if verbose, _ := rootCmd.PersistentFlags().GetBool("verbose"); verbose {
+		if queued {
+			fmt.Printf("Database is locked, queued visit to: %s (from: %s)\n", absDir, previousDir)
+		} else {
+			fmt.Printf("Added visit to: %s (from: %s)\n", absDir, previousDir)
+		}
 	}
+}
 
-	// Save database
-	if err := db.Save(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error saving database: %v\n", err)
-		os.Exit(1)
+// recordVisit adds a visit coming from previousDir, if set, and saves it.
+// It runs on every cd, so it only waits briefly for the database lock: if
+// another process holds it longer the visit is queued for the next save
+// and recordVisit reports that it was.
+func recordVisit(absDir, previousDir string) bool {
+	// Get database config
+	cfg := GetConfig()
+	opts := newOpenOptions(cfg)
+	opts.LockTimeout = seconds(cfg.AddLockTimeout, defaultAddLockTimeout)
+
+	// Open database, add visit and save database
+	db, err := zoink.Open(cfg.DatabasePath, opts)
+	if err == nil {
+		err = db.AddFrom(absDir, previousDir)
+		if err == nil {
+			err = db.Save()
+		}
+	}
+	if !errors.Is(err, zoink.ErrLocked) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error adding visit: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		return false
 	}
 
-	// Only print success in verbose mode to avoid cluttering shell output
-	if verbose, _ := rootCmd.PersistentFlags().GetBool("verbose"); verbose {
-		fmt.Printf("Added visit to: %s (from: %s)\n", absDir, previousDir)
+	// Queue the visit instead of blocking the shell
+	if err := zoink.QueueVisit(cfg.DatabasePath, absDir, previousDir, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error queueing visit: %v\n", err)
+		os.Exit(exitCode(err))
 	}
+	return true
 }
 
 // handleRemove removes a directory from the database
@@ -334,12 +673,11 @@ func handleRemove(dir string) {
 	absDir, err := filepath.Abs(dir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error resolving path '%s': %v\n", dir, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 
 	// Get database config
 	cfg := GetConfig()
-	dbConfig := database.DatabaseConfig{Path: cfg.DatabasePath}
 
 	// Check if database exists
 	if _, err := os.Stat(cfg.DatabasePath); os.IsNotExist(err) {
@@ -348,44 +686,120 @@ func handleRemove(dir string) {
 	}
 
 	// Open database
-	db, err := database.New(dbConfig)
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
-	defer db.Close()
 
-	// Check if directory exists in database
-	entries, err := db.GetAll()
+	// Remove directory
+	var removed bool
+	err = db.UpdateUndoable("remove", func(tx *zoink.Tx) error {
+		var err error
+		removed, err = tx.Remove(absDir)
+		return err
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting entries: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error removing directory: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	if !removed {
+		fmt.Fprintf(os.Stderr, "Directory '%s' is not in the database\n", absDir)
+		os.Exit(exitNotFound)
 	}
 
-	found := false
-	for _, entry := range entries {
-		if entry.Path == absDir {
-			found = true
-			break
-		}
+	fmt.Printf("Removed: %s (run 'zoink undo' to restore it)\n", absDir)
+}
+
+// handleMove rewrites all entries below oldPrefix to newPrefix
+func handleMove(oldPrefix, newPrefix string) {
+	// Convert to absolute paths
+	absOld, err := filepath.Abs(oldPrefix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving path '%s': %v\n", oldPrefix, err)
+		os.Exit(exitCode(err))
+	}
+	absNew, err := filepath.Abs(newPrefix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving path '%s': %v\n", newPrefix, err)
+		os.Exit(exitCode(err))
 	}
 
-	if !found {
-		fmt.Printf("Directory '%s' is not in the database\n", absDir)
+	// Get database config
+	cfg := GetConfig()
+
+	// Check if database exists
+	if _, err := os.Stat(cfg.DatabasePath); os.IsNotExist(err) {
+		fmt.Println("Database does not exist yet")
 		return
 	}
 
-	// Remove directory
-	if err := db.RemoveDirectory(absDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Error removing directory: %v\n", err)
-		os.Exit(1)
+	// Open database
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
 	}
 
-	// Save database
-	if err := db.Save(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error saving database: %v\n", err)
-		os.Exit(1)
+	var moved, merged int
+	err = db.UpdateUndoable("mv", func(tx *zoink.Tx) error {
+		var err error
+		moved, merged, err = tx.Move(absOld, absNew)
+		return err
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error moving entries: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	if moved == 0 {
+		fmt.Printf("No entries found under '%s'\n", absOld)
+		return
+	}
+
+	fmt.Printf("Moved %d entries from %s to %s", moved, absOld, absNew)
+	if merged > 0 {
+		fmt.Printf(" (%d merged with existing entries)", merged)
+	}
+	fmt.Println()
+}
+
+// handleUndo reverts the last destructive operation
+func handleUndo() {
+	// Get database config
+	cfg := GetConfig()
+
+	// Check if database exists
+	if _, err := os.Stat(cfg.DatabasePath); os.IsNotExist(err) {
+		fmt.Println("Database does not exist yet")
+		return
+	}
+
+	// Open database
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	last, err := db.LastOperation()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading journal: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	if last == nil {
+		fmt.Println("Nothing to undo")
+		return
+	}
+
+	operation, err := db.Undo()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error undoing %s: %v\n", last.Name, err)
+		os.Exit(exitCode(err))
 	}
 
-	fmt.Printf("Removed: %s\n", absDir)
+	fmt.Printf("Undid %s from %s (%d entries reverted)\n",
+		operation.Name,
+		operation.Time.Format("2006-01-02 15:04"),
+		operation.Entries)
 }
@@ -1,24 +1,33 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
-	"github.com/iammatthew2/zoink/internal/database"
+	"github.com/iammatthew2/zoink/pkg/zoink"
 	"github.com/spf13/cobra"
 )
 
 // NavigationConfig holds the configuration for navigation operations
 type NavigationConfig struct {
-	Interactive bool
-	ListOnly    bool
-	EchoOnly    bool
-	Recent      bool
-	Frequent    bool
-	MaxResults  int
-	Threshold   float64
+	Interactive    bool
+	ListOnly       bool
+	EchoOnly       bool
+	Recent         bool
+	Frequent       bool
+	IncludeOffline bool
+	MatchNotes     bool
+	WithNotes      bool
+	TimeAware      bool
+	Regex          string
+	Glob           string
+	JSON           bool
+	MaxResults     int
+	Threshold      float64
 }
 
 // buildConfigFromFlags extracts navigation configuration from command flags with optional config overrides
@@ -31,6 +40,13 @@ func buildConfigFromFlags(cmd *cobra.Command) *NavigationConfig {
 	echoOnly, _ := cmd.Flags().GetBool("echo")
 	recent, _ := cmd.Flags().GetBool("recent")
 	frequent, _ := cmd.Flags().GetBool("frequent")
+	includeOffline, _ := cmd.Flags().GetBool("include-offline")
+	matchNotes, _ := cmd.Flags().GetBool("notes")
+	withNotes, _ := cmd.Flags().GetBool("with-notes")
+	timeAware, _ := cmd.Flags().GetBool("time-aware")
+	regex, _ := cmd.Flags().GetString("regex")
+	glob, _ := cmd.Flags().GetString("glob")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
 
 	// Use config defaults for advanced settings
 	maxResults := cfg.MaxResults
@@ -44,13 +60,20 @@ func buildConfigFromFlags(cmd *cobra.Command) *NavigationConfig {
 	}
 
 	return &NavigationConfig{
-		Interactive: interactive,
-		ListOnly:    listOnly,
-		EchoOnly:    echoOnly,
-		Recent:      recent,
-		Frequent:    frequent,
-		MaxResults:  maxResults,
-		Threshold:   threshold,
+		Interactive:    interactive,
+		ListOnly:       listOnly,
+		EchoOnly:       echoOnly,
+		Recent:         recent,
+		Frequent:       frequent,
+		IncludeOffline: includeOffline,
+		MatchNotes:     matchNotes || cfg.SearchNotes,
+		WithNotes:      withNotes,
+		TimeAware:      timeAware || cfg.TimeAware,
+		Regex:          regex,
+		Glob:           glob,
+		JSON:           jsonOutput,
+		MaxResults:     maxResults,
+		Threshold:      threshold,
 	}
 }
 
@@ -58,7 +81,6 @@ func buildConfigFromFlags(cmd *cobra.Command) *NavigationConfig {
 func handleNavigation(query string, config *NavigationConfig) {
 	// Get database config
 	cfg := GetConfig()
-	dbConfig := database.DatabaseConfig{Path: cfg.DatabasePath}
 
 	// Check if database exists
 	if _, err := os.Stat(cfg.DatabasePath); os.IsNotExist(err) {
@@ -67,49 +89,87 @@ func handleNavigation(query string, config *NavigationConfig) {
 			return
 		}
 		fmt.Fprintf(os.Stderr, "Database does not exist yet. Visit some directories first.\n")
-		os.Exit(1)
+		os.Exit(exitNotFound)
 	}
 
 	// Open database
-	db, err := database.New(dbConfig)
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 	defer db.Close()
 
 	// Query database
-	var entries []*database.DirectoryEntry
-	if query == "" {
-		// No query - get all entries for interactive selection
-		entries, err = db.GetAll()
+	var entries []zoink.Result
+	description := query
+	if config.Regex != "" || config.Glob != "" {
+		// Path pattern - filter by it and rank by frecency only
+		var match func(string) bool
+		if config.Regex != "" {
+			description = config.Regex
+			match, err = zoink.MatchRegexp(config.Regex)
+		} else {
+			description = config.Glob
+			match, err = zoink.MatchGlob(config.Glob)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		entries, err = db.SearchPaths(match, zoink.SearchOptions{
+			MaxResults:     config.MaxResults,
+			IncludeOffline: config.IncludeOffline,
+		})
+	} else if query == "" {
+		// No query - rank all entries for interactive selection
+		entries, err = db.Search("", zoink.SearchOptions{
+			IncludeOffline: config.IncludeOffline,
+			From:           currentDir(),
+			TimeAware:      config.TimeAware,
+		})
 	} else {
 		// Query with search term
-		entries, err = db.Query(query, config.MaxResults)
+		entries, err = db.Search(query, zoink.SearchOptions{
+			MaxResults:     config.MaxResults,
+			IncludeOffline: config.IncludeOffline,
+			MatchNotes:     config.MatchNotes,
+			From:           currentDir(),
+			TimeAware:      config.TimeAware,
+		})
 	}
 
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error querying database: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+
+	// Handle JSON output, including an empty result
+	if config.JSON {
+		if err := printDirectoryJSON(entries); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		return
 	}
 
 	// Handle no results
 	if len(entries) == 0 {
 		if config.ListOnly {
-			if query == "" {
+			if description == "" {
 				fmt.Println("Database is empty")
 			} else {
-				fmt.Printf("No directories found matching '%s'\n", query)
+				fmt.Printf("No directories found matching '%s'\n", description)
 			}
 			return
 		}
-		fmt.Fprintf(os.Stderr, "No directories found matching '%s'\n", query)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "No directories found matching '%s'\n", description)
+		os.Exit(exitNotFound)
 	}
 
 	// Handle list-only mode
 	if config.ListOnly {
-		printDirectoryList(entries, config.EchoOnly)
+		printDirectoryList(entries, config.EchoOnly, config.WithNotes)
 		return
 	}
 
@@ -119,6 +179,11 @@ func handleNavigation(query string, config *NavigationConfig) {
 		os.Exit(1)
 	}
 
+	// A pick from several interactive results teaches the ranking
+	if config.Interactive && query != "" && len(entries) > 1 {
+		db.RecordChoice(query, selectedPath)
+	}
+
 	// Output the selected path
 	if config.EchoOnly {
 		// No newline for shell integration
@@ -129,7 +194,7 @@ func handleNavigation(query string, config *NavigationConfig) {
 }
 
 // selectDirectory handles directory selection logic
-func selectDirectory(entries []*database.DirectoryEntry, config *NavigationConfig) string {
+func selectDirectory(entries []zoink.Result, config *NavigationConfig) string {
 	// Single result - return it directly
 	if len(entries) == 1 {
 		return entries[0].Path
@@ -145,7 +210,7 @@ func selectDirectory(entries []*database.DirectoryEntry, config *NavigationConfi
 }
 
 // selectInteractively shows an interactive selection menu
-func selectInteractively(entries []*database.DirectoryEntry) string {
+func selectInteractively(entries []zoink.Result) string {
 	if len(entries) == 0 {
 		return ""
 	}
@@ -153,10 +218,14 @@ func selectInteractively(entries []*database.DirectoryEntry) string {
 	// Create options for selection
 	var options []string
 	for _, entry := range entries {
-		options = append(options, entry.Path)
+		option := entry.Path
+		if entry.Note != "" {
+			option += "  (" + entry.Note + ")"
+		}
+		options = append(options, option)
 	}
 
-	var selected string
+	var selected int
 	prompt := &survey.Select{
 		Message: "Select directory:",
 		Options: options,
@@ -166,15 +235,19 @@ func selectInteractively(entries []*database.DirectoryEntry) string {
 		return "" // User cancelled
 	}
 
-	return selected
+	return entries[selected].Path
 }
 
 // printDirectoryList prints a formatted list of directories
-func printDirectoryList(entries []*database.DirectoryEntry, simpleFormat bool) {
+func printDirectoryList(entries []zoink.Result, simpleFormat bool, withNotes bool) {
 	if simpleFormat {
-		// just paths, one per line
+		// just paths, one per line, with the note after a tab if requested
 		for _, entry := range entries {
-			fmt.Println(entry.Path)
+			if withNotes && entry.Note != "" {
+				fmt.Printf("%s\t%s\n", entry.Path, entry.Note)
+			} else {
+				fmt.Println(entry.Path)
+			}
 		}
 		return
 	}
@@ -189,15 +262,72 @@ func printDirectoryList(entries []*database.DirectoryEntry, simpleFormat bool) {
 
 	for i, entry := range entries {
 		fmt.Printf("  %d. %s\n", i+1, entry.Path)
-		fmt.Printf("     Visits: %d | Last: %s\n",
-			entry.VisitCount,
-			formatLastVisit(entry.LastVisited))
+		fmt.Printf("     Visits: %d | Last: %s",
+			entry.Visits,
+			formatLastVisit(entry.LastVisited.Unix()))
+		if entry.Pinned {
+			fmt.Print(" | pinned")
+		}
+		if len(entry.Tags) > 0 {
+			fmt.Printf(" | #%s", strings.Join(entry.Tags, " #"))
+		}
+		if entry.Offline {
+			fmt.Print(" | offline")
+		}
+		fmt.Println()
+		if entry.Note != "" {
+			fmt.Printf("     Note: %s\n", entry.Note)
+		}
 		if i < len(entries)-1 {
 			fmt.Println()
 		}
 	}
 }
 
+// currentDir returns the working directory, or "" if it can't be determined
+func currentDir() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+// entryJSON is the JSON representation of a directory entry
+type entryJSON struct {
+	Path         string    `json:"path"`
+	Visits       int       `json:"visits"`
+	FirstVisited time.Time `json:"first_visited"`
+	LastVisited  time.Time `json:"last_visited"`
+	Frecency     float64   `json:"frecency"`
+	Pinned       bool      `json:"pinned,omitempty"`
+	Offline      bool      `json:"offline,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	Note         string    `json:"note,omitempty"`
+}
+
+// printDirectoryJSON prints entries as a JSON array in ranking order
+func printDirectoryJSON(entries []zoink.Result) error {
+	output := make([]entryJSON, 0, len(entries))
+	for _, entry := range entries {
+		output = append(output, entryJSON{
+			Path:         entry.Path,
+			Visits:       entry.Visits,
+			FirstVisited: entry.FirstVisited,
+			LastVisited:  entry.LastVisited,
+			Frecency:     entry.Frecency,
+			Pinned:       entry.Pinned,
+			Offline:      entry.Offline,
+			Tags:         entry.Tags,
+			Note:         entry.Note,
+		})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
 // formatLastVisit formats the last visit timestamp
 func formatLastVisit(timestamp int64) string {
 	lastVisited := time.Unix(timestamp, 0)
@@ -222,10 +352,10 @@ func formatLastVisit(timestamp int64) string {
 
 // handleEmptyQuery case when no query is provided: navigate to previous directory
 func handleEmptyQuery() {
-	previousPath, err := database.GetPreviousPath()
+	previousPath, err := zoink.PreviousDir()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "no previous directory available\n")
-		os.Exit(1)
+		os.Exit(exitNotFound)
 	}
 
 	fmt.Print(previousPath)
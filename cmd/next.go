@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/iammatthew2/zoink/pkg/zoink"
+	"github.com/spf13/cobra"
+)
+
+// nextCmd represents the next command
+var nextCmd = &cobra.Command{
+	Use:   "next [directory]",
+	Short: "List the directories you usually go to next",
+	Long: `List the directories most often visited right after the current one
+(or the given directory), based on recent moves between directories.
+
+Examples:
+  zoink next                     Where to from here?
+  zoink next ~/code/api -n 3     Top 3 destinations from ~/code/api
+  cd "$(zoink next --echo)"      Go to the most likely next directory`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  handleNextCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(nextCmd)
+
+	nextCmd.Flags().IntP("max-results", "n", 5, "Maximum number of directories to list")
+	nextCmd.Flags().BoolP("echo", "e", false, "Print the most likely directory only")
+}
+
+// handleNextCommand lists likely next directories
+func handleNextCommand(cmd *cobra.Command, args []string) {
+	maxResults, _ := cmd.Flags().GetInt("max-results")
+	echoOnly, _ := cmd.Flags().GetBool("echo")
+
+	from := "."
+	if len(args) > 0 {
+		from = args[0]
+	}
+	absFrom, err := filepath.Abs(from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving path '%s': %v\n", from, err)
+		os.Exit(exitCode(err))
+	}
+
+	// Get database config
+	cfg := GetConfig()
+
+	// Check if database exists
+	if _, err := os.Stat(cfg.DatabasePath); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Database does not exist yet. Visit some directories first.\n")
+		os.Exit(exitNotFound)
+	}
+
+	// Open database
+	db, err := zoink.OpenReadOnly(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer db.Close()
+
+	predictions, err := db.Next(absFrom, maxResults)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error querying database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	if len(predictions) == 0 {
+		fmt.Fprintf(os.Stderr, "No recorded moves from %s yet\n", absFrom)
+		os.Exit(exitNotFound)
+	}
+
+	if echoOnly {
+		fmt.Print(predictions[0].Entry.Path)
+		return
+	}
+
+	for i, prediction := range predictions {
+		fmt.Printf("  %d. %s (%.0f%%)\n", i+1, prediction.Entry.Path, prediction.Share*100)
+	}
+}
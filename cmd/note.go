@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iammatthew2/zoink/pkg/zoink"
+	"github.com/spf13/cobra"
+)
+
+// noteCmd represents the note command
+var noteCmd = &cobra.Command{
+	Use:   "note <directory> [text...]",
+	Short: "Annotate a directory with a note",
+	Long: `Attach a free-text note to a directory, adding the directory if needed.
+Without text, the current note is printed.
+
+Notes are shown by 'z --list' and the interactive picker. With 'z --notes'
+(or "search_notes": true in the config file), query words that don't match
+a directory name can match its note instead, at a lower weight.
+
+Examples:
+  zoink note ~/code/svc2 payments gateway, prod
+  zoink note ~/code/svc2               Show the note
+  zoink note ~/code/svc2 --clear       Remove the note
+  z --notes payments                   Finds svc2 through its note`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  handleNoteCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(noteCmd)
+
+	noteCmd.Flags().Bool("clear", false, "Remove the note")
+}
+
+// handleNoteCommand sets, clears or prints the note of a directory
+func handleNoteCommand(cmd *cobra.Command, args []string) {
+	clearNote, _ := cmd.Flags().GetBool("clear")
+	text := strings.Join(args[1:], " ")
+
+	// Convert to absolute path
+	absDir, err := filepath.Abs(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving path '%s': %v\n", args[0], err)
+		os.Exit(exitCode(err))
+	}
+
+	// Get database config
+	cfg := GetConfig()
+
+	// Open database
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer db.Close()
+
+	if text == "" && !clearNote {
+		entry, exists := db.Get(absDir)
+		if !exists || entry.Note == "" {
+			fmt.Printf("No note for %s\n", absDir)
+			return
+		}
+		fmt.Println(entry.Note)
+		return
+	}
+
+	if !clearNote {
+		// Annotating an untracked directory adds it
+		if _, err := os.Stat(absDir); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: Directory '%s' does not exist\n", absDir)
+			os.Exit(1)
+		}
+		if _, err := db.AddScanned(absDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error adding directory: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+	} else {
+		text = ""
+	}
+
+	if err := db.SetNote(absDir, text); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	// Save database
+	if err := db.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	if clearNote {
+		fmt.Printf("Removed note from %s\n", absDir)
+	} else {
+		fmt.Printf("Noted: %s\n", absDir)
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/iammatthew2/zoink/pkg/zoink"
+	"github.com/spf13/cobra"
+)
+
+// pinCmd represents the pin command
+var pinCmd = &cobra.Command{
+	Use:   "pin [directory]",
+	Short: "Pin a directory so it always ranks first for its matches",
+	Long: `Pin a directory so it always wins over unpinned matches, regardless of
+recent activity elsewhere. Pinned directories are never evicted.
+
+Without an argument, lists the pinned directories.
+
+Examples:
+  zoink pin ~/code/monorepo      Pin a directory
+  zoink pin                      List pinned directories
+  zoink unpin ~/code/monorepo    Remove the pin`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			handleListPinned()
+			return
+		}
+		handlePin(args[0], true)
+	},
+}
+
+// unpinCmd represents the unpin command
+var unpinCmd = &cobra.Command{
+	Use:   "unpin <directory>",
+	Short: "Remove the pin from a directory",
+	Long:  `Remove the pin from a directory so it is ranked like any other entry again.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		handlePin(args[0], false)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+}
+
+// handlePin pins or unpins a directory, adding it to the database if needed
+func handlePin(dir string, pinned bool) {
+	// Convert to absolute path
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving path '%s': %v\n", dir, err)
+		os.Exit(exitCode(err))
+	}
+
+	// Get database config
+	cfg := GetConfig()
+
+	// Open database
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer db.Close()
+
+	if pinned {
+		// Pinning an untracked directory adds it
+		if _, err := os.Stat(absDir); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: Directory '%s' does not exist\n", absDir)
+			os.Exit(1)
+		}
+		if _, err := db.AddScanned(absDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error adding directory: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+	}
+
+	if err := db.SetPinned(absDir, pinned); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	// Save database
+	if err := db.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	if pinned {
+		fmt.Printf("Pinned: %s\n", absDir)
+	} else {
+		fmt.Printf("Unpinned: %s\n", absDir)
+	}
+}
+
+// handleListPinned prints all pinned directories
+func handleListPinned() {
+	// Get database config
+	cfg := GetConfig()
+
+	// Check if database exists
+	if _, err := os.Stat(cfg.DatabasePath); os.IsNotExist(err) {
+		fmt.Println("Database does not exist yet")
+		return
+	}
+
+	// Open database
+	db, err := zoink.OpenReadOnly(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer db.Close()
+
+	pinned := db.Filter(zoink.Pinned())
+
+	if len(pinned) == 0 {
+		fmt.Println("No pinned directories")
+		return
+	}
+
+	for _, entry := range pinned {
+		fmt.Println(entry.Path)
+	}
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/iammatthew2/zoink/pkg/zoink"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove entries matching rules",
+	Long: `Remove every entry matching all of the given filters.
+
+The matching entries are shown first and removal has to be confirmed.
+Pruning can be reverted with 'zoink undo'.
+
+Examples:
+  zoink prune --older-than 180d                Forget directories unused for 6 months
+  zoink prune --visits-below 3 --older-than 30d
+  zoink prune --under /tmp                     Forget everything below /tmp
+  zoink prune --matching 'build-*'             Glob on the basename (or full path)
+  zoink prune --frecency-below 0.5 --dry-run   Only show what would be removed`,
+	Args: cobra.NoArgs,
+	Run:  handlePruneCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.Flags().String("older-than", "", "Last visited longer ago than this (e.g. 36h, 180d, 6w, 3mo, 1y)")
+	pruneCmd.Flags().Uint32("visits-below", 0, "Visited fewer than this many times")
+	pruneCmd.Flags().StringSlice("under", nil, "At or below this directory (repeatable: any of)")
+	pruneCmd.Flags().StringSlice("matching", nil, "Path matches this glob (repeatable: any of)")
+	pruneCmd.Flags().Float64("frecency-below", 0, "Frecency score below this value")
+	pruneCmd.Flags().BoolP("dry-run", "n", false, "Show matching entries without removing them")
+	pruneCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+}
+
+// buildPruneFilter combines the filter flags into a single filter. Returns
+// false if no filter was given.
+func buildPruneFilter(cmd *cobra.Command) (zoink.Filter, bool, error) {
+	var filters []zoink.Filter
+
+	if olderThan, _ := cmd.Flags().GetString("older-than"); olderThan != "" {
+		age, err := zoink.ParseAge(olderThan)
+		if err != nil {
+			return zoink.Filter{}, false, err
+		}
+		filters = append(filters, zoink.OlderThan(age))
+	}
+
+	if visitsBelow, _ := cmd.Flags().GetUint32("visits-below"); visitsBelow > 0 {
+		filters = append(filters, zoink.VisitsBelow(int(visitsBelow)))
+	}
+
+	if under, _ := cmd.Flags().GetStringSlice("under"); len(under) > 0 {
+		var prefixes []zoink.Filter
+		for _, dir := range under {
+			absDir, err := filepath.Abs(dir)
+			if err != nil {
+				return zoink.Filter{}, false, err
+			}
+			prefixes = append(prefixes, zoink.Under(absDir))
+		}
+		filters = append(filters, zoink.AnyOf(prefixes...))
+	}
+
+	if patterns, _ := cmd.Flags().GetStringSlice("matching"); len(patterns) > 0 {
+		var globs []zoink.Filter
+		for _, pattern := range patterns {
+			glob, err := zoink.Matching(pattern)
+			if err != nil {
+				return zoink.Filter{}, false, err
+			}
+			globs = append(globs, glob)
+		}
+		filters = append(filters, zoink.AnyOf(globs...))
+	}
+
+	if cmd.Flags().Changed("frecency-below") {
+		frecencyBelow, _ := cmd.Flags().GetFloat64("frecency-below")
+		filters = append(filters, zoink.FrecencyBelow(frecencyBelow))
+	}
+
+	return zoink.AllOf(filters...), len(filters) > 0, nil
+}
+
+// handlePruneCommand removes entries matching the filter flags
+func handlePruneCommand(cmd *cobra.Command, args []string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	assumeYes, _ := cmd.Flags().GetBool("yes")
+
+	// Get database config
+	cfg := GetConfig()
+
+	filter, hasFilter, err := buildPruneFilter(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	if !hasFilter {
+		fmt.Fprintf(os.Stderr, "Error: at least one filter is required (see 'zoink prune --help')\n")
+		os.Exit(1)
+	}
+
+	// Check if database exists
+	if _, err := os.Stat(cfg.DatabasePath); os.IsNotExist(err) {
+		fmt.Println("Database does not exist yet")
+		return
+	}
+
+	// Open database
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	matches := db.Filter(filter)
+
+	if len(matches) == 0 {
+		fmt.Println("No entries match - nothing to prune")
+		return
+	}
+
+	printEntryTable(matches)
+	fmt.Println()
+
+	if dryRun {
+		fmt.Printf("Would prune %d entries (dry run)\n", len(matches))
+		return
+	}
+
+	if !assumeYes {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Fprintf(os.Stderr, "Error: refusing to prune without confirmation (use --yes)\n")
+			os.Exit(1)
+		}
+
+		confirmed := false
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Prune these %d entries?", len(matches)),
+		}
+		if err := survey.AskOne(prompt, &confirmed); err != nil || !confirmed {
+			fmt.Println("Nothing pruned")
+			return
+		}
+	}
+
+	var removed int
+	// Remove exactly the confirmed entries, even if other entries started
+	// matching while the prompt was open
+	err = db.UpdateUndoable("prune", func(tx *zoink.Tx) error {
+		for _, entry := range matches {
+			ok, err := tx.Remove(entry.Path)
+			if err != nil {
+				return err
+			}
+			if ok {
+				removed++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error pruning entries: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	fmt.Printf("Pruned %d entries. Run 'zoink undo' to restore them.\n", removed)
+}
+
+// printEntryTable prints entries as an aligned table
+func printEntryTable(entries []zoink.Entry) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PATH\tVISITS\tLAST VISIT\tFRECENCY")
+	for _, entry := range entries {
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%.2f\n",
+			entry.Path, entry.Visits, formatLastVisit(entry.LastVisited.Unix()), entry.Frecency)
+	}
+	writer.Flush()
+}
@@ -3,8 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/iammatthew2/zoink/internal/config"
+	"github.com/iammatthew2/zoink/pkg/zoink"
 	"github.com/spf13/cobra"
 )
 
@@ -75,3 +77,24 @@ func initConfig() {
 func GetConfig() *config.Config {
 	return cfg
 }
+
+// newOpenOptions builds the database options from the loaded config
+func newOpenOptions(cfg *config.Config) zoink.Options {
+	return zoink.Options{
+		MaxEntries:  cfg.MaxEntries,
+		LockTimeout: seconds(cfg.LockTimeout, 0),
+	}
+}
+
+// defaultAddLockTimeout is how long 'zoink add' waits for the database lock
+// before queueing the visit
+const defaultAddLockTimeout = 200 * time.Millisecond
+
+// seconds converts a duration in seconds from the config, using fallback
+// when it isn't set (a zero fallback leaves the library default)
+func seconds(s float64, fallback time.Duration) time.Duration {
+	if s <= 0 {
+		return fallback
+	}
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/iammatthew2/zoink/internal/scan"
+	"github.com/iammatthew2/zoink/pkg/zoink"
+	"github.com/spf13/cobra"
+)
+
+// scanCmd represents the scan command
+var scanCmd = &cobra.Command{
+	Use:   "scan <roots...>",
+	Short: "Seed the database by scanning for project roots",
+	Long: `Walk one or more directory trees and add every project root found.
+
+A directory is a project root when it contains one of the project markers
+(.git, go.mod, package.json, Cargo.toml by default, or "project_markers"
+from the config file). Scanned directories get a low seed score so they
+rank below directories you have actually visited.
+
+Examples:
+  zoink scan ~/code                    Scan a single tree
+  zoink scan ~/code ~/work -d 3        Limit the scan depth
+  zoink scan ~ --exclude 'vendor'      Skip extra directories
+  zoink scan ~/code --dry-run          Show what would be added`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  handleScanCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+
+	scanCmd.Flags().IntP("max-depth", "d", 0, "Maximum depth below each root (0 = unlimited)")
+	scanCmd.Flags().BoolP("dry-run", "n", false, "Show project roots without adding them")
+	scanCmd.Flags().StringSliceP("exclude", "x", nil, "Additional exclude patterns (glob)")
+	scanCmd.Flags().StringSliceP("marker", "m", nil, "Additional project marker file or directory names")
+}
+
+// handleScanCommand scans the given roots and seeds the database
+func handleScanCommand(cmd *cobra.Command, args []string) {
+	maxDepth, _ := cmd.Flags().GetInt("max-depth")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	excludes, _ := cmd.Flags().GetStringSlice("exclude")
+	markers, _ := cmd.Flags().GetStringSlice("marker")
+
+	cfg := GetConfig()
+
+	// Config markers replace the defaults, flag markers extend them
+	baseMarkers := scan.DefaultMarkers
+	if len(cfg.ProjectMarkers) > 0 {
+		baseMarkers = cfg.ProjectMarkers
+	}
+
+	opts := scan.Options{
+		Markers:  append(append([]string{}, baseMarkers...), markers...),
+		Exclude:  append(append([]string{}, cfg.ExcludePatterns...), excludes...),
+		MaxDepth: maxDepth,
+	}
+
+	projects, err := scan.FindProjects(args, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	if len(projects) == 0 {
+		fmt.Println("No project roots found")
+		return
+	}
+
+	if dryRun {
+		fmt.Printf("Found %d project roots (dry run, nothing added):\n", len(projects))
+		for _, path := range projects {
+			fmt.Printf("  + %s\n", path)
+		}
+		return
+	}
+
+	// Open database
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer db.Close()
+
+	added := 0
+	for _, path := range projects {
+		inserted, err := db.AddScanned(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error adding %s: %v\n", path, err)
+			continue
+		}
+		if inserted {
+			added++
+			if verbose, _ := rootCmd.PersistentFlags().GetBool("verbose"); verbose {
+				fmt.Printf("  + %s\n", path)
+			}
+		}
+	}
+
+	// Save database
+	if err := db.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	fmt.Printf("Found %d project roots: added %d, %d already tracked\n",
+		len(projects), added, len(projects)-added)
+}
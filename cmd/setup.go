@@ -197,7 +197,7 @@ func handleInteractiveSetup(shells []ShellInfo) {
 
 	if err := survey.AskOne(prompt, &selected); err != nil {
 		fmt.Fprintf(os.Stderr, "Setup cancelled: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 
 	if len(selected) == 0 {
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/iammatthew2/zoink/pkg/zoink"
+	"github.com/spf13/cobra"
+)
+
+// tagCmd represents the tag command
+var tagCmd = &cobra.Command{
+	Use:   "tag <directory> <tag...>",
+	Short: "Tag a directory",
+	Long: `Add one or more tags to a directory, adding the directory if needed.
+
+Tags group directories, e.g. by client. Prefix a query word with '#' to only
+match directories carrying that tag. Quote it in bash and fish, where '#'
+starts a comment.
+
+Examples:
+  zoink tag ~/work/acme-api clientA backend
+  z '#clientA' api               Best 'api' match tagged clientA
+  zoink tags                     List tags
+  zoink tags clientA             List directories tagged clientA`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		handleTag(args[0], args[1:])
+	},
+}
+
+// untagCmd represents the untag command
+var untagCmd = &cobra.Command{
+	Use:   "untag <directory> [tag...]",
+	Short: "Remove tags from a directory",
+	Long:  `Remove the given tags from a directory, or all of its tags if none are given.`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		handleUntag(args[0], args[1:])
+	},
+}
+
+// tagsCmd represents the tags command
+var tagsCmd = &cobra.Command{
+	Use:   "tags [tag]",
+	Short: "List tags, or the directories carrying a tag",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			handleListTags()
+			return
+		}
+		handleListTagged(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(untagCmd)
+	rootCmd.AddCommand(tagsCmd)
+}
+
+// handleTag adds tags to a directory
+func handleTag(dir string, tags []string) {
+	// Convert to absolute path
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving path '%s': %v\n", dir, err)
+		os.Exit(exitCode(err))
+	}
+	if _, err := os.Stat(absDir); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error: Directory '%s' does not exist\n", absDir)
+		os.Exit(1)
+	}
+
+	// Get database config
+	cfg := GetConfig()
+
+	// Open database
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer db.Close()
+
+	// Tagging an untracked directory adds it
+	if _, err := db.AddScanned(absDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error adding directory: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	if err := db.AddTags(absDir, tags...); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	// Save database
+	if err := db.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	fmt.Printf("Tagged: %s\n", absDir)
+}
+
+// handleUntag removes tags from a directory
+func handleUntag(dir string, tags []string) {
+	// Convert to absolute path
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving path '%s': %v\n", dir, err)
+		os.Exit(exitCode(err))
+	}
+
+	// Get database config
+	cfg := GetConfig()
+
+	// Open database
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer db.Close()
+
+	removed, err := db.RemoveTags(absDir, tags...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	// Save database
+	if err := db.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	fmt.Printf("Removed %d tags from %s\n", removed, absDir)
+}
+
+// handleListTags prints every tag with the number of directories carrying it
+func handleListTags() {
+	// Get database config
+	cfg := GetConfig()
+
+	// Check if database exists
+	if _, err := os.Stat(cfg.DatabasePath); os.IsNotExist(err) {
+		fmt.Println("Database does not exist yet")
+		return
+	}
+
+	// Open database
+	db, err := zoink.OpenReadOnly(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer db.Close()
+
+	counts := db.TagCounts()
+	if len(counts) == 0 {
+		fmt.Println("No tags yet - add some with 'zoink tag <dir> <tag...>'")
+		return
+	}
+
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	for _, tag := range tags {
+		fmt.Printf("#%s (%d)\n", tag, counts[tag])
+	}
+}
+
+// handleListTagged prints the directories carrying a tag
+func handleListTagged(tag string) {
+	// Get database config
+	cfg := GetConfig()
+
+	// Check if database exists
+	if _, err := os.Stat(cfg.DatabasePath); os.IsNotExist(err) {
+		fmt.Println("Database does not exist yet")
+		return
+	}
+
+	// Open database
+	db, err := zoink.OpenReadOnly(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer db.Close()
+
+	entries := db.Filter(zoink.Tagged(tag))
+
+	if len(entries) == 0 {
+		fmt.Printf("No directories tagged '%s'\n", tag)
+		return
+	}
+
+	for _, entry := range entries {
+		fmt.Println(entry.Path)
+	}
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/iammatthew2/zoink/internal/watch"
+	"github.com/iammatthew2/zoink/pkg/zoink"
+	"github.com/spf13/cobra"
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Follow directory renames as they happen (Linux)",
+	Long: `Run in the foreground and keep the database in sync with directory renames.
+
+The parent directories of your most frecent entries are watched with inotify.
+When a directory is renamed or moved between watched directories (by a file
+manager, git mv, etc.), every entry at or below it is rewritten to the new
+location instead of being left behind for 'zoink clean' to delete.
+
+Examples:
+  zoink watch                    Watch the parents of the top 100 entries
+  zoink watch --top 500          Watch more directories
+  zoink watch --refresh 1m       Re-evaluate the watched set every minute`,
+	Args: cobra.NoArgs,
+	Run:  handleWatchCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().IntP("top", "n", 100, "Number of top entries whose parent directories are watched")
+	watchCmd.Flags().Duration("refresh", 10*time.Minute, "How often to reload the database and update watches")
+}
+
+// handleWatchCommand watches directories and rewrites renamed entries
+func handleWatchCommand(cmd *cobra.Command, args []string) {
+	top, _ := cmd.Flags().GetInt("top")
+	refresh, _ := cmd.Flags().GetDuration("refresh")
+	verbose, _ := rootCmd.PersistentFlags().GetBool("verbose")
+
+	cfg := GetConfig()
+	// Open database. It is deliberately not closed on exit: all changes are
+	// saved through Update, and a final save would overwrite newer visits.
+	db, err := zoink.Open(cfg.DatabasePath, newOpenOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	watcher, err := watch.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting watcher: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	defer watcher.Close()
+
+	if err := updateWatches(db, watcher, top, verbose); err != nil {
+		fmt.Fprintf(os.Stderr, "Error watching directories: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case rename, ok := <-watcher.Renames:
+			if !ok {
+				return
+			}
+			handleRename(db, rename)
+
+		case err := <-watcher.Errors:
+			fmt.Fprintf(os.Stderr, "Error watching directories: %v\n", err)
+			os.Exit(exitCode(err))
+
+		case <-ticker.C:
+			if err := db.Reload(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reloading database: %v\n", err)
+				continue
+			}
+			if err := updateWatches(db, watcher, top, verbose); err != nil {
+				fmt.Fprintf(os.Stderr, "Error watching directories: %v\n", err)
+			}
+
+		case <-signals:
+			return
+		}
+	}
+}
+
+// updateWatches points the watcher at the parents of the top entries
+func updateWatches(db *zoink.DB, watcher *watch.Watcher, top int, verbose bool) error {
+	entries, err := db.Search("", zoink.SearchOptions{MaxResults: top})
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, entry := range entries {
+		parent := filepath.Dir(entry.Path)
+		if !seen[parent] {
+			seen[parent] = true
+			dirs = append(dirs, parent)
+		}
+	}
+
+	watched, err := watcher.Watch(dirs)
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Watching %d directories for %d entries\n", watched, len(entries))
+	}
+
+	return err
+}
+
+// handleRename rewrites the entries affected by a rename under the database lock
+func handleRename(db *zoink.DB, rename watch.Rename) {
+	var moved, merged int
+	err := db.Update(func(tx *zoink.Tx) error {
+		var err error
+		moved, merged, err = tx.Move(rename.From, rename.To)
+		return err
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error rewriting %s: %v\n", rename.From, err)
+		return
+	}
+
+	if moved > 0 {
+		fmt.Printf("%s %s -> %s (%d entries, %d merged)\n",
+			time.Now().Format("2006-01-02 15:04:05"), rename.From, rename.To, moved, merged)
+	}
+}
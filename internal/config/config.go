@@ -11,8 +11,18 @@ type Config struct {
 	DatabasePath    string   `json:"database_path"`
 	ExcludePatterns []string `json:"exclude_patterns"`
 	// Optional user overrides (only present if customized)
-	MaxResults int     `json:"max_results,omitempty"`
-	Threshold  float64 `json:"threshold,omitempty"`
+	MaxResults     int      `json:"max_results,omitempty"`
+	Threshold      float64  `json:"threshold,omitempty"`
+	ProjectMarkers []string `json:"project_markers,omitempty"`
+	SearchRoots    []string `json:"search_roots,omitempty"`
+	MaxEntries     int      `json:"max_entries,omitempty"`
+	SearchNotes    bool     `json:"search_notes,omitempty"`
+	TimeAware      bool     `json:"time_aware,omitempty"`
+	// Seconds to wait for another zoink process holding the database lock,
+	// for commands in general and for the shell hook's 'zoink add', which
+	// queues the visit instead of failing
+	LockTimeout    float64 `json:"lock_timeout,omitempty"`
+	AddLockTimeout float64 `json:"add_lock_timeout,omitempty"`
 }
 
 // Default returns a config with minimal required settings
@@ -2,6 +2,8 @@ package database
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
 	"path/filepath"
 	"testing"
 )
@@ -85,3 +87,60 @@ func BenchmarkDatabaseLoad(b *testing.B) {
 		db.Close()
 	}
 }
+
+// benchmarkWords are combined into realistic directory names
+var benchmarkWords = []string{
+	"api", "server", "client", "web", "app", "core", "utils", "config",
+	"docs", "build", "test", "data", "infra", "deploy", "auth", "billing",
+	"search", "metrics", "worker", "gateway", "frontend", "backend", "cli",
+}
+
+// populateBenchmarkDatabase fills a database with n distinct directories
+func populateBenchmarkDatabase(b *testing.B, n int) *Database {
+	b.Helper()
+	db, err := New(DatabaseConfig{Path: filepath.Join(b.TempDir(), "bench.db")})
+	if err != nil {
+		b.Fatalf("Failed to create database: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		first := benchmarkWords[rng.Intn(len(benchmarkWords))]
+		second := benchmarkWords[rng.Intn(len(benchmarkWords))]
+		db.addVisit(fmt.Sprintf("/home/user/src/%s/%s-%s%d", first, first, second, i), db.clock())
+	}
+	return db
+}
+
+func BenchmarkQueryScaling(b *testing.B) {
+	queries := []string{"gateway", "apisrv", "'billing", "zzz"}
+
+	for _, size := range []int{10_000, 100_000, 1_000_000} {
+		db := populateBenchmarkDatabase(b, size)
+
+		for _, mode := range []struct {
+			name     string
+			minIndex int
+		}{
+			{"indexed", 0},
+			{"linear", math.MaxInt},
+		} {
+			b.Run(fmt.Sprintf("entries=%d/%s", size, mode.name), func(b *testing.B) {
+				db.indexMinEntries = mode.minIndex
+				// Build the index outside the timed loop
+				if _, err := db.Query(queries[0], 10); err != nil {
+					b.Fatalf("Query failed: %v", err)
+				}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := db.Query(queries[i%len(queries)], 10); err != nil {
+						b.Fatalf("Query failed: %v", err)
+					}
+				}
+			})
+		}
+
+		db.Close()
+	}
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// maxChoicesPerQuery bounds the paths remembered for one query
+	maxChoicesPerQuery = 8
+	// maxChoiceQueries bounds the number of queries choices are kept for
+	maxChoiceQueries = 500
+	// choiceBoost is how much a path's share of the choices made for a
+	// query can raise its score (2.0 = up to triple)
+	choiceBoost = 2.0
+	// similarChoiceWeight scales choices made for a query that is a prefix
+	// of the current one, or the other way around
+	similarChoiceWeight = 0.5
+	// choiceConfidence is the choice weight at which a path gets half of
+	// the boost, so a single pick counts less than a repeated one
+	choiceConfidence = 2.0
+)
+
+// normalizeChoiceQuery maps equivalent queries to the same key
+func normalizeChoiceQuery(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// RecordChoice remembers that path was picked from the results of query,
+// so it ranks higher for the same and similar queries. Choices decay like
+// transitions.
+func (db *Database) RecordChoice(query, path string) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	key := normalizeChoiceQuery(query)
+	if key == "" {
+		return nil
+	}
+
+	cleanPath := filepath.Clean(path)
+	if !db.isTracked(cleanPath) {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
+	db.choices[key] = addTransition(db.choices[key], cleanPath, db.clock().Unix(), maxChoicesPerQuery)
+
+	return nil
+}
+
+// ForgetChoices forgets the choices recorded for a query, or all choices if
+// query is empty. It returns the number of choices forgotten.
+func (db *Database) ForgetChoices(query string) int {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	forgotten := 0
+	if key := normalizeChoiceQuery(query); key != "" {
+		forgotten = len(db.choices[key])
+		delete(db.choices, key)
+		return forgotten
+	}
+
+	for _, targets := range db.choices {
+		forgotten += len(targets)
+	}
+	db.choices = make(map[string][]*Transition)
+
+	return forgotten
+}
+
+// choiceShares returns a 0-1 strength for each path picked for query: its
+// share of the picks, discounted while there are few of them. Choices for
+// similar queries count less (caller must hold lock)
+func (db *Database) choiceShares(query string, now int64) map[string]float64 {
+	key := normalizeChoiceQuery(query)
+	if key == "" || len(db.choices) == 0 {
+		return nil
+	}
+
+	shares := choiceStrengths(db.choices[key], now)
+	for other, targets := range db.choices {
+		if other == key || !(strings.HasPrefix(key, other) || strings.HasPrefix(other, key)) {
+			continue
+		}
+		if shares == nil {
+			shares = make(map[string]float64)
+		}
+		for path, share := range choiceStrengths(targets, now) {
+			shares[path] += share * similarChoiceWeight
+		}
+	}
+
+	for path, share := range shares {
+		if share > 1 {
+			shares[path] = 1
+		}
+	}
+
+	return shares
+}
+
+// choiceStrengths returns weight / (total weight + choiceConfidence) per path
+func choiceStrengths(targets []*Transition, now int64) map[string]float64 {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	total := 0.0
+	strengths := make(map[string]float64, len(targets))
+	for _, choice := range targets {
+		weight := choice.decayedWeight(now)
+		strengths[choice.To] += weight
+		total += weight
+	}
+	for path := range strengths {
+		strengths[path] /= total + choiceConfidence
+	}
+
+	return strengths
+}
+
+// moveChoices rewrites chosen paths at or below oldPrefix to newPrefix,
+// journaling the rows it changes (caller must hold lock)
+func (db *Database) moveChoices(oldPrefix, newPrefix string) {
+	if db.journal != nil {
+		var rows []string
+		for query, targets := range db.choices {
+			if linksUnder(targets, oldPrefix) {
+				rows = append(rows, query)
+			}
+		}
+		rememberRows(&db.journal.Choices, db.choices, rows)
+	}
+	unchanged := func(query string) string { return query }
+	db.choices = rewriteGraph(db.choices, unchanged, movedPath(oldPrefix, newPrefix), db.clock().Unix())
+}
+
+// pruneChoices forgets decayed choices and choices of directories no longer
+// tracked (caller must hold lock)
+func (db *Database) pruneChoices(now int64) {
+	anyQuery := func(string) bool { return true }
+	pruneGraph(db.choices, now, anyQuery, db.isTracked, maxChoiceQueries)
+}
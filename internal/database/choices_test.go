@@ -0,0 +1,88 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRepeatedChoiceRisesToFirst(t *testing.T) {
+	tempDir := t.TempDir()
+	clock := &fakeClock{now: time.Now()}
+	config := DatabaseConfig{Path: filepath.Join(tempDir, "test.db"), Clock: clock.Now}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	// Same basename, decreasing frecency
+	paths := []string{"/home/user/a/proj", "/home/user/b/proj", "/home/user/c/proj", "/home/user/d/proj"}
+	for i, path := range paths {
+		for j := 0; j < 5-i; j++ {
+			db.AddVisit(path)
+		}
+	}
+
+	results, _ := db.Query("proj", 10)
+	if results[3].Path != "/home/user/d/proj" {
+		t.Fatalf("Expected d/proj last before any choice, got %v", results)
+	}
+
+	// The user keeps picking the 4th result
+	picks := 0
+	for results[0].Path != "/home/user/d/proj" {
+		if picks == 10 {
+			t.Fatalf("Expected d/proj first after 10 picks, got %s", results[0].Path)
+		}
+		if err := db.RecordChoice("proj", "/home/user/d/proj"); err != nil {
+			t.Fatalf("Failed to record choice: %v", err)
+		}
+		picks++
+		results, _ = db.Query("proj", 10)
+	}
+	if picks < 2 {
+		t.Errorf("Expected a single pick not to override frecency, got first after %d", picks)
+	}
+
+	// Similar queries benefit less, unrelated ones not at all
+	results, _ = db.Query("pro", 10)
+	if results[3].Path == "/home/user/d/proj" {
+		t.Error("Expected a boost for a prefix of the chosen query")
+	}
+	if db.choiceShares("other", clock.now.Unix()) != nil {
+		t.Error("Expected no boost for an unrelated query")
+	}
+
+	// Choices survive a save/load round trip and decay over time
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+	db2, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	results, _ = db2.Query(" proj ", 10)
+	if results[0].Path != "/home/user/d/proj" {
+		t.Errorf("Expected d/proj first after reload, got %s", results[0].Path)
+	}
+
+	clock.now = clock.now.Add(365 * 24 * time.Hour)
+	if share := db2.choiceShares("proj", clock.now.Unix())["/home/user/d/proj"]; share > 0.01 {
+		t.Errorf("Expected the choice to have decayed, got strength %.2f", share)
+	}
+	clock.now = clock.now.Add(-365 * 24 * time.Hour)
+
+	// Forgetting resets the ranking
+	if forgotten := db2.ForgetChoices("proj"); forgotten != 1 {
+		t.Errorf("Expected 1 choice forgotten, got %d", forgotten)
+	}
+	results, _ = db2.Query("proj", 10)
+	if results[0].Path != "/home/user/a/proj" {
+		t.Errorf("Expected frecency order after forgetting, got %s first", results[0].Path)
+	}
+
+	if err := db2.RecordChoice("proj", "/home/user/untracked"); err == nil {
+		t.Error("Expected an error recording an untracked path")
+	}
+}
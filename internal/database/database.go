@@ -7,12 +7,55 @@ import (
 	"math"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+)
+
+// EntrySource records how an entry first made it into the database
+type EntrySource uint8
+
+const (
+	// SourceVisit is an entry recorded by actually visiting the directory
+	SourceVisit EntrySource = iota
+	// SourceScan is an entry discovered by 'zoink scan' and never visited since
+	SourceScan
+)
+
+// String returns the name used for the source in output
+func (s EntrySource) String() string {
+	switch s {
+	case SourceVisit:
+		return "visit"
+	case SourceScan:
+		return "scan"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// databaseMagic is the file header ("ZOIN")
+	databaseMagic = 0x5A4F494E
+	// databaseVersion is the current on-disk format version
+	databaseVersion = 11
+
+	// scanSeedVisits is the visit count given to entries discovered by a scan
+	scanSeedVisits = 1
+	// scanFrecencyWeight scales the frecency of scanned entries so they
+	// rank below directories that were actually visited
+	scanFrecencyWeight = 0.1
+)
+
+// EntryFlags holds boolean state persisted with an entry
+type EntryFlags uint8
 
-	"github.com/gofrs/flock"
+const (
+	// FlagOffline marks an entry on a volume that wasn't mounted at the last cleanup
+	FlagOffline EntryFlags = 1 << iota
+	// FlagPinned marks an entry that always ranks first among its matches
+	FlagPinned
 )
 
 // DirectoryEntry represents a single directory with frecency data
@@ -21,6 +64,21 @@ type DirectoryEntry struct {
 	VisitCount   uint32
 	LastVisited  int64 // Unix timestamp
 	FirstVisited int64 // Unix timestamp
+	Source       EntrySource
+	Flags        EntryFlags
+	Tags         []string // sorted, without the leading '#'
+	Note         string
+	TimeSlots    []SlotCount // visits per hour of the week, sorted by slot
+}
+
+// IsOffline reports whether the entry was on an unmounted volume at the last cleanup
+func (e *DirectoryEntry) IsOffline() bool {
+	return e.Flags&FlagOffline != 0
+}
+
+// IsPinned reports whether the entry is pinned to the top of its matches
+func (e *DirectoryEntry) IsPinned() bool {
+	return e.Flags&FlagPinned != 0
 }
 
 // MatchResult represents a search result with both fuzzy and frecency scores
@@ -31,28 +89,110 @@ type MatchResult struct {
 	CombinedScore float64
 }
 
+// QueryOptions tunes how Query selects and ranks entries
+type QueryOptions struct {
+	// MaxResults limits the number of entries returned (0 = unlimited)
+	MaxResults int
+	// IncludeOffline also returns entries on volumes that aren't mounted
+	IncludeOffline bool
+	// MatchNotes lets query words that don't match a path match its note
+	MatchNotes bool
+	// From is the current directory; entries often visited next from it
+	// are ranked higher
+	From string
+	// TimeAware ranks entries usually visited at the current hour of the
+	// week higher
+	TimeAware bool
+}
+
 // Database manages the binary database of directory entries
 type Database struct {
-	path    string
-	entries map[string]*DirectoryEntry
-	mutex   sync.RWMutex
+	path       string
+	entries    map[string]*DirectoryEntry
+	mutex      sync.RWMutex
+	mounts     *MountTable
+	mountsOnce sync.Once
+	journal    *JournalOperation // destructive operation being recorded
+	journaled  map[string]bool   // paths already recorded in journal
+	maxEntries int
+	evicted    uint64 // total entries evicted over the database lifetime
+	// transitions counts moves between directories, keyed by source
+	transitions map[string][]*Transition
+	// choices counts paths picked interactively, keyed by normalized query
+	choices map[string][]*Transition
+	clock   func() time.Time
+	// fs is used to check whether directories still exist
+	fs FileSystem
+	// readOnly databases are never written
+	readOnly bool
+	// index prunes query candidates on large databases; built on first use
+	index           *nameIndex
+	indexMutex      sync.Mutex
+	indexMinEntries int
+	// lockTimeout bounds waiting for the database file lock
+	lockTimeout time.Duration
+	// spoolReplayed is the ID of the last spool whose visits were saved
+	spoolReplayed string
+	// imports records how far each history file was imported, keyed by
+	// path; replaced rather than changed in place
+	imports map[string]ImportMarker
 }
 
 // DatabaseConfig holds configuration for the database
 type DatabaseConfig struct {
 	Path string
+	// Mounts overrides the mount table used to detect offline volumes.
+	// When nil, the system mount table is loaded on first use.
+	Mounts *MountTable
+	// MaxEntries caps the number of entries; the lowest-frecency entries
+	// beyond it are evicted on save (0 = unlimited)
+	MaxEntries int
+	// Clock returns the current time (default time.Now). Visits are
+	// bucketed into time slots in the location of the times it returns.
+	Clock func() time.Time
+	// FS is used to check whether tracked directories still exist
+	// (default: the operating system's file system)
+	FS FileSystem
+	// ReadOnly opens the database without creating its directory and
+	// refuses to save it
+	ReadOnly bool
+	// LockTimeout bounds how long loading and saving wait for another
+	// process holding the database lock before failing with ErrLocked
+	// (default 10s)
+	LockTimeout time.Duration
 }
 
 // New creates a new database instance
 func New(config DatabaseConfig) (*Database, error) {
 	db := &Database{
-		path:    config.Path,
-		entries: make(map[string]*DirectoryEntry),
+		path:        config.Path,
+		entries:     make(map[string]*DirectoryEntry),
+		mounts:      config.Mounts,
+		maxEntries:  config.MaxEntries,
+		transitions: make(map[string][]*Transition),
+		choices:     make(map[string][]*Transition),
+		clock:       config.Clock,
+		fs:          config.FS,
+		readOnly:    config.ReadOnly,
+
+		indexMinEntries: indexMinEntries,
+		lockTimeout:     defaultLockTimeout,
+	}
+	if db.clock == nil {
+		db.clock = time.Now
+	}
+	if db.fs == nil {
+		db.fs = osFileSystem{}
+	}
+	if config.LockTimeout > 0 {
+		db.lockTimeout = config.LockTimeout
 	}
 
 	// Create directory if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(config.Path), 0755); err != nil {
-		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	if !config.ReadOnly {
+		if err := os.MkdirAll(filepath.Dir(config.Path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
 	}
 
 	// Load existing data
@@ -63,7 +203,8 @@ func New(config DatabaseConfig) (*Database, error) {
 	return db, nil
 }
 
-// AddVisit records a visit to a directory with optional previous directory
+// AddVisit records a visit to a directory with optional previous directory.
+// The move from the previous directory is counted for 'zoink next'.
 func (db *Database) AddVisit(path string, previousPath ...string) error {
 	// Save previous directory if provided
 	if len(previousPath) > 0 && previousPath[0] != "" {
@@ -73,108 +214,266 @@ func (db *Database) AddVisit(path string, previousPath ...string) error {
 	db.mutex.Lock()
 	defer db.mutex.Unlock()
 
+	now := db.clock()
+	db.addVisit(path, now)
+	if len(previousPath) > 0 && previousPath[0] != "" {
+		db.recordTransition(previousPath[0], path, now.Unix())
+	}
+
+	return nil
+}
+
+// AddVisitAt records a visit that happened at the given Unix timestamp, such
+// as one imported from shell history. Visits older than the entry's last
+// visit only extend its history and don't move LastVisited back.
+func (db *Database) AddVisitAt(path string, timestamp int64) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	db.addVisit(path, time.Unix(timestamp, 0).In(db.clock().Location()))
+
+	return nil
+}
+
+// addVisit records a visit at the given time (caller must hold lock)
+func (db *Database) addVisit(path string, visited time.Time) {
 	// Clean and normalize path
 	cleanPath := filepath.Clean(path)
-	now := time.Now().Unix()
+	timestamp := visited.Unix()
+	slot := timeSlot(visited)
 
-	entry, exists := db.entries[cleanPath]
+	entry, exists := db.mutableEntry(cleanPath)
 	if exists {
 		entry.VisitCount++
-		entry.LastVisited = now
+		if timestamp > entry.LastVisited {
+			entry.LastVisited = timestamp
+		}
+		if timestamp < entry.FirstVisited {
+			entry.FirstVisited = timestamp
+		}
+		// A real visit promotes a scanned entry to a regular one and
+		// proves its volume is mounted
+		entry.Source = SourceVisit
+		entry.Flags &^= FlagOffline
+		entry.TimeSlots = addTimeSlot(entry.TimeSlots, slot)
 	} else {
-		db.entries[cleanPath] = &DirectoryEntry{
+		db.putEntry(&DirectoryEntry{
 			Path:         cleanPath,
 			VisitCount:   1,
-			LastVisited:  now,
-			FirstVisited: now,
-		}
+			LastVisited:  timestamp,
+			FirstVisited: timestamp,
+			TimeSlots:    []SlotCount{{Slot: slot, Count: 1}},
+		})
+	}
+}
+
+// AddScanned inserts a directory discovered by a scan with a low seed visit
+// count. Directories already in the database are left untouched. It reports
+// whether a new entry was created.
+func (db *Database) AddScanned(path string) (bool, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	cleanPath := filepath.Clean(path)
+	if _, exists := db.entries[cleanPath]; exists {
+		return false, nil
 	}
 
-	return nil
+	now := db.clock().Unix()
+	db.putEntry(&DirectoryEntry{
+		Path:         cleanPath,
+		VisitCount:   scanSeedVisits,
+		LastVisited:  now,
+		FirstVisited: now,
+		Source:       SourceScan,
+	})
+
+	return true, nil
 }
 
 // Query searches for directories matching the given query using fuzzy matching combined with frecency
 func (db *Database) Query(query string, maxResults int) ([]*DirectoryEntry, error) {
+	return db.QueryWithOptions(query, QueryOptions{MaxResults: maxResults})
+}
+
+// QueryWithOptions is Query with additional control over selection and ranking
+func (db *Database) QueryWithOptions(query string, opts QueryOptions) ([]*DirectoryEntry, error) {
+	matches, err := db.QueryScored(query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return matchedEntries(matches), nil
+}
+
+// matchedEntries returns the entries of ranked matches
+func matchedEntries(matches []MatchResult) []*DirectoryEntry {
+	var entries []*DirectoryEntry
+	for _, match := range matches {
+		entries = append(entries, match.Entry)
+	}
+	return entries
+}
+
+// QueryScored is QueryWithOptions returning the scores behind the ranking.
+// With an empty query, the combined score is the boosted frecency.
+func (db *Database) QueryScored(query string, opts QueryOptions) ([]MatchResult, error) {
 	db.mutex.RLock()
 	defer db.mutex.RUnlock()
 
-	if query == "" {
-		// No query - return all entries sorted by frecency
-		var entries []*DirectoryEntry
-		for _, entry := range db.entries {
-			entries = append(entries, entry)
-		}
-
-		// Sort by frecency score only
-		sort.Slice(entries, func(i, j int) bool {
-			return calculateFrecency(entries[i]) > calculateFrecency(entries[j])
-		})
+	maxResults := opts.MaxResults
+	if maxResults <= 0 {
+		maxResults = len(db.entries)
+	}
 
-		// Limit results
-		if len(entries) > maxResults {
-			entries = entries[:maxResults]
+	// Entries picked for this query before, often visited next from the
+	// current directory, or usually visited at this time of the week get
+	// a boost
+	now := db.clock()
+	choices := db.choiceShares(query, now.Unix())
+	var shares map[string]float64
+	if opts.From != "" {
+		shares = db.transitionShares(opts.From, now.Unix())
+	}
+	boost := func(entry *DirectoryEntry, score float64) float64 {
+		score *= 1 + choiceBoost*choices[entry.Path]
+		score *= 1 + transitionBoost*shares[entry.Path]
+		if opts.TimeAware {
+			score *= 1 + timeSlotBoost*timeSlotShare(entry, now)
 		}
-
-		return entries, nil
+		return score
 	}
 
-	var matches []MatchResult
+	// "#tag" words restrict the candidates before any ranking
+	tags, query := splitTagQuery(query)
 
-	// Fuzzy match against all entries
-	for _, entry := range db.entries {
-		fuzzyScore := fuzzyMatch(entry.Path, query)
-		if fuzzyScore > 0 {
-			frecencyScore := calculateFrecency(entry)
-
-			// Combine fuzzy and frecency scores
-			// Normalize fuzzy score to 0-1 range (assuming max score around 1000)
-			normalizedFuzzy := float64(fuzzyScore) / 1000.0
-			if normalizedFuzzy > 1.0 {
-				normalizedFuzzy = 1.0
+	if query == "" {
+		// No query - rank all entries, pinned first, then by frecency only
+		entries := make([]*DirectoryEntry, 0, len(db.entries))
+		for _, entry := range db.entries {
+			entries = append(entries, entry)
+		}
+		return rankMatches(entries, maxResults, func(entry *DirectoryEntry) (MatchResult, bool) {
+			if !entry.hasAllTags(tags) {
+				return MatchResult{}, false
 			}
-
-			// Combine with weights: 60% fuzzy matching, 40% frecency
-			combinedScore := (normalizedFuzzy * 0.6) + (frecencyScore * 0.4)
-
-			matches = append(matches, MatchResult{
+			if !opts.IncludeOffline && !db.isAvailable(entry) {
+				return MatchResult{}, false
+			}
+			frecencyScore := calculateFrecency(entry, now.Unix())
+			return MatchResult{
 				Entry:         entry,
-				FuzzyScore:    fuzzyScore,
 				FrecencyScore: frecencyScore,
-				CombinedScore: combinedScore,
-			})
+				CombinedScore: boost(entry, frecencyScore),
+			}, true
+		}), nil
+	}
+
+	// Match the query terms against all entries, or only those the index
+	// can't rule out
+	expr := ParseQuery(query)
+	candidates, ok := db.indexCandidates(expr, opts)
+	if !ok {
+		candidates = make([]*DirectoryEntry, 0, len(db.entries))
+		for _, entry := range db.entries {
+			candidates = append(candidates, entry)
 		}
 	}
 
-	// Sort by combined score
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].CombinedScore > matches[j].CombinedScore
-	})
+	score := func(entry *DirectoryEntry) (MatchResult, bool) {
+		if !entry.hasAllTags(tags) {
+			return MatchResult{}, false
+		}
+		fuzzyScore := expr.Score(entry.Path)
+		if fuzzyScore == 0 && opts.MatchNotes {
+			fuzzyScore = noteFallbackScore(entry, query)
+		}
+		if fuzzyScore == 0 {
+			return MatchResult{}, false
+		}
+		if !opts.IncludeOffline && !db.isAvailable(entry) {
+			return MatchResult{}, false
+		}
 
-	// Convert to DirectoryEntry slice
-	var entries []*DirectoryEntry
-	for _, match := range matches {
-		entries = append(entries, match.Entry)
-	}
+		frecencyScore := calculateFrecency(entry, now.Unix())
+
+		// Combine fuzzy and frecency scores
+		// Normalize fuzzy score to 0-1 range (assuming max score around 1000)
+		normalizedFuzzy := float64(fuzzyScore) / 1000.0
+		if normalizedFuzzy > 1.0 {
+			normalizedFuzzy = 1.0
+		}
 
-	// Limit results
-	if len(entries) > maxResults {
-		entries = entries[:maxResults]
+		// Combine with weights: 60% fuzzy matching, 40% frecency
+		combinedScore := boost(entry, (normalizedFuzzy*0.6)+(frecencyScore*0.4))
+
+		return MatchResult{
+			Entry:         entry,
+			FuzzyScore:    fuzzyScore,
+			FrecencyScore: frecencyScore,
+			CombinedScore: combinedScore,
+		}, true
 	}
 
-	return entries, nil
+	// Keep the best matches: pinned ones in a top tier, then by combined score
+	return rankMatches(candidates, maxResults, score), nil
 }
 
-// GetAll returns all directory entries
+// GetAll returns all directory entries, pinned first, then by frecency
 func (db *Database) GetAll() ([]*DirectoryEntry, error) {
 	db.mutex.RLock()
 	defer db.mutex.RUnlock()
 
+	return db.allEntries(), nil
+}
+
+// allEntries is GetAll (caller must hold lock)
+func (db *Database) allEntries() []*DirectoryEntry {
 	entries := make([]*DirectoryEntry, 0, len(db.entries))
 	for _, entry := range db.entries {
 		entries = append(entries, entry)
 	}
+	now := db.clock().Unix()
+	rankEntries(entries, func(entry *DirectoryEntry) float64 {
+		return calculateFrecency(entry, now)
+	})
+	return entries
+}
+
+// Get returns a copy of the entry for a path
+func (db *Database) Get(path string) (DirectoryEntry, bool) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	entry, exists := db.entries[filepath.Clean(path)]
+	if !exists {
+		return DirectoryEntry{}, false
+	}
+	return *entry, true
+}
 
-	return entries, nil
+// Now returns the current time according to the database's clock. Time
+// based predicates such as OlderThan are built with it.
+func (db *Database) Now() time.Time {
+	return db.clock()
+}
+
+// Len returns the number of entries
+func (db *Database) Len() int {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	return len(db.entries)
+}
+
+// isAvailable reports whether an entry can be shown in results. Offline
+// entries are rechecked so a volume that was mounted again shows up right
+// away instead of only after the next cleanup.
+func (db *Database) isAvailable(entry *DirectoryEntry) bool {
+	if !entry.IsOffline() {
+		return true
+	}
+	_, err := db.fs.Stat(entry.Path)
+	return err == nil
 }
 
 // RemoveDirectory removes a directory from the database
@@ -182,22 +481,179 @@ func (db *Database) RemoveDirectory(path string) error {
 	db.mutex.Lock()
 	defer db.mutex.Unlock()
 
+	db.removeDirectory(path)
+
+	return nil
+}
+
+// removeDirectory removes a directory and reports whether it was tracked
+// (caller must hold lock)
+func (db *Database) removeDirectory(path string) bool {
 	cleanPath := filepath.Clean(path)
-	delete(db.entries, cleanPath)
+	if _, exists := db.entries[cleanPath]; !exists {
+		return false
+	}
+	db.rememberRemoval(cleanPath)
+	db.deleteEntry(cleanPath)
+	return true
+}
+
+// MovePrefix rewrites every entry at or below oldPrefix to live under
+// newPrefix instead. When the destination path is already tracked the two
+// entries are merged: visit counts are summed and the visit range widened.
+// It returns the number of entries moved and how many of those were merged.
+func (db *Database) MovePrefix(oldPrefix, newPrefix string) (int, int, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	return db.movePrefix(oldPrefix, newPrefix)
+}
+
+// movePrefix is MovePrefix (caller must hold lock)
+func (db *Database) movePrefix(oldPrefix, newPrefix string) (int, int, error) {
+	oldPrefix = filepath.Clean(oldPrefix)
+	newPrefix = filepath.Clean(newPrefix)
+	if oldPrefix == newPrefix {
+		return 0, 0, nil
+	}
+	if hasPathPrefix(newPrefix, oldPrefix) {
+		return 0, 0, fmt.Errorf("cannot move %s into itself", oldPrefix)
+	}
+
+	// Collect first so the map isn't modified while iterating
+	var toMove []*DirectoryEntry
+	for path, entry := range db.entries {
+		if hasPathPrefix(path, oldPrefix) {
+			toMove = append(toMove, entry)
+		}
+	}
+
+	// Detach every source first so destinations that are themselves being
+	// moved (e.g. moving a directory into its parent) aren't merged into
+	for _, entry := range toMove {
+		db.remember(entry.Path)
+		db.remember(newPrefix + strings.TrimPrefix(entry.Path, oldPrefix))
+	}
+	for _, entry := range toMove {
+		db.deleteEntry(entry.Path)
+	}
+
+	merged := 0
+	for _, entry := range toMove {
+		moved := *entry
+		moved.Path = newPrefix + strings.TrimPrefix(entry.Path, oldPrefix)
+
+		existing, exists := db.mutableEntry(moved.Path)
+		if !exists {
+			db.putEntry(&moved)
+			continue
+		}
+
+		mergeEntry(existing, &moved)
+		merged++
+	}
+	db.moveTransitions(oldPrefix, newPrefix)
+	db.moveChoices(oldPrefix, newPrefix)
+
+	return len(toMove), merged, nil
+}
+
+// mergeEntry folds the visit history of src into dst
+func mergeEntry(dst, src *DirectoryEntry) {
+	dst.VisitCount += src.VisitCount
+	if src.LastVisited > dst.LastVisited {
+		dst.LastVisited = src.LastVisited
+	}
+	if src.FirstVisited < dst.FirstVisited {
+		dst.FirstVisited = src.FirstVisited
+	}
+	if src.Source == SourceVisit {
+		dst.Source = SourceVisit
+	}
+	dst.Tags = mergeTags(dst.Tags, src.Tags)
+	if dst.Note == "" {
+		dst.Note = src.Note
+	}
+	dst.TimeSlots = mergeTimeSlots(dst.TimeSlots, src.TimeSlots)
+}
+
+// hasPathPrefix reports whether path is prefix itself or lies below it
+func hasPathPrefix(path, prefix string) bool {
+	if path == prefix {
+		return true
+	}
+	if prefix == string(filepath.Separator) {
+		return strings.HasPrefix(path, prefix)
+	}
+	return strings.HasPrefix(path, prefix+string(filepath.Separator))
+}
+
+// CheckPath reports whether a directory exists, was deleted, or lives on a
+// volume that isn't currently mounted
+func (db *Database) CheckPath(path string) PathStatus {
+	db.mountsOnce.Do(func() {
+		if db.mounts == nil {
+			db.mounts = LoadMountTable()
+		}
+	})
+
+	return db.mounts.checkPath(path, db.fs.Stat)
+}
+
+// SetOffline marks or clears the offline flag of an entry
+func (db *Database) SetOffline(path string, offline bool) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	return db.setFlag(path, FlagOffline, offline)
+}
+
+// SetPinned pins or unpins an entry
+func (db *Database) SetPinned(path string, pinned bool) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	return db.setFlag(path, FlagPinned, pinned)
+}
+
+// setFlag sets or clears a flag on an entry (caller must hold lock)
+func (db *Database) setFlag(path string, flag EntryFlags, on bool) error {
+	entry, exists := db.mutableEntry(filepath.Clean(path))
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
+	if on {
+		entry.Flags |= flag
+	} else {
+		entry.Flags &^= flag
+	}
 
 	return nil
 }
 
-// CleanupMissing removes directories that no longer exist
+// CleanupMissing removes directories that no longer exist. Directories on
+// volumes that aren't mounted are kept and marked offline instead; entries
+// that are reachable again lose their offline mark.
 func (db *Database) CleanupMissing() (int, error) {
 	db.mutex.Lock()
 	defer db.mutex.Unlock()
 
 	removed := 0
-	for path := range db.entries {
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			delete(db.entries, path)
+	for path, entry := range db.entries {
+		switch db.CheckPath(path) {
+		case PathMissing:
+			db.rememberRemoval(path)
+			db.deleteEntry(path)
 			removed++
+		case PathOffline:
+			if !entry.IsOffline() {
+				db.setFlag(path, FlagOffline, true)
+			}
+		default:
+			if entry.IsOffline() {
+				db.setFlag(path, FlagOffline, false)
+			}
 		}
 	}
 
@@ -206,23 +662,43 @@ func (db *Database) CleanupMissing() (int, error) {
 
 // Save persists the database to disk
 func (db *Database) Save() error {
-	// Create file lock to prevent concurrent access from multiple processes
-	lockFile := flock.New(db.path + ".lock")
+	if db.readOnly {
+		return ErrReadOnly
+	}
 
-	// Acquire exclusive lock (blocks if another process is saving)
-	if err := lockFile.Lock(); err != nil {
-		return fmt.Errorf("failed to acquire database lock: %w", err)
+	// Acquire exclusive lock to prevent concurrent access from multiple
+	// processes (waits while another process is saving)
+	lockFile, err := db.lock(true)
+	if err != nil {
+		return err
 	}
 	defer lockFile.Unlock()
 
-	db.mutex.RLock()
-	defer db.mutex.RUnlock()
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
 
-	return db.save()
+	if err := db.save(); err != nil {
+		return err
+	}
+
+	return db.flushJournal()
 }
 
-// Close saves the database and cleans up resources
+// Reload replaces the in-memory entries with the current contents of the
+// database file, picking up changes saved by other processes
+func (db *Database) Reload() error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	return db.load()
+}
+
+// Close saves the database and cleans up resources. Read-only databases
+// are left untouched.
 func (db *Database) Close() error {
+	if db.readOnly {
+		return nil
+	}
 	return db.Save()
 }
 
@@ -283,6 +759,14 @@ func GetPreviousPath() (string, error) {
 
 // save writes the database to disk (caller must hold lock)
 func (db *Database) save() error {
+	replayed, err := db.replaySpool()
+	if err != nil {
+		return err
+	}
+	db.evictOverflow()
+	db.pruneTransitions(db.clock().Unix())
+	db.pruneChoices(db.clock().Unix())
+
 	// Write to temporary file first for atomic operation
 	tempPath := db.path + ".tmp"
 	file, err := os.Create(tempPath)
@@ -292,10 +776,10 @@ func (db *Database) save() error {
 	defer file.Close()
 
 	// Write magic header and version
-	if err := binary.Write(file, binary.LittleEndian, uint32(0x5A4F494E)); err != nil { // "ZOIN"
+	if err := binary.Write(file, binary.LittleEndian, uint32(databaseMagic)); err != nil {
 		return fmt.Errorf("failed to write magic: %w", err)
 	}
-	if err := binary.Write(file, binary.LittleEndian, uint32(1)); err != nil { // Version 1
+	if err := binary.Write(file, binary.LittleEndian, uint32(databaseVersion)); err != nil {
 		return fmt.Errorf("failed to write version: %w", err)
 	}
 
@@ -304,13 +788,42 @@ func (db *Database) save() error {
 		return fmt.Errorf("failed to write entry count: %w", err)
 	}
 
-	// Write each entry
-	for _, entry := range db.entries {
+	// Write each entry, sorted by path so the file only changes with its data
+	for _, entry := range db.sortedEntries() {
 		if err := writeEntry(file, entry); err != nil {
 			return fmt.Errorf("failed to write entry: %w", err)
 		}
 	}
 
+	// Version 4: eviction counter
+	if err := binary.Write(file, binary.LittleEndian, db.evicted); err != nil {
+		return fmt.Errorf("failed to write eviction count: %w", err)
+	}
+
+	// Version 7: transition graph
+	if err := writeTransitions(file, db.transitions); err != nil {
+		return fmt.Errorf("failed to write transitions: %w", err)
+	}
+
+	// Version 9: interactive choices
+	if err := writeTransitions(file, db.choices); err != nil {
+		return fmt.Errorf("failed to write choices: %w", err)
+	}
+
+	// Version 10: last replayed spool
+	if err := writeString(file, db.spoolReplayed); err != nil {
+		return fmt.Errorf("failed to write spool ID: %w", err)
+	}
+
+	// Version 11: history import markers
+	if err := writeImports(file, db.imports); err != nil {
+		return fmt.Errorf("failed to write import markers: %w", err)
+	}
+
+	// Flush to disk so the replaced file survives a crash
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync database: %w", err)
+	}
 	file.Close()
 
 	// Atomic replace
@@ -319,24 +832,37 @@ func (db *Database) save() error {
 		return fmt.Errorf("failed to replace database file: %w", err)
 	}
 
+	// The spooled visits are saved now
+	if replayed != "" {
+		os.Remove(replayed)
+	}
+
 	return nil
 }
 
 // load reads the database from disk
 func (db *Database) load() error {
-	// Create shared lock to prevent loading while another process is saving
-	lockFile := flock.New(db.path + ".lock")
-
-	// Acquire shared lock (allows multiple readers, blocks writers)
-	if err := lockFile.RLock(); err != nil {
+	// Acquire shared lock to prevent loading while another process is
+	// saving (allows multiple readers, blocks writers)
+	lockFile, err := db.lock(false)
+	if err != nil {
 		// If we can't get the lock, it might be a new database
 		if os.IsNotExist(err) {
 			return nil
 		}
+		// Read-only databases may live where the lock file can't be created
+		if db.readOnly && os.IsPermission(err) {
+			return db.loadFile()
+		}
 		return fmt.Errorf("failed to acquire read lock: %w", err)
 	}
 	defer lockFile.Unlock()
 
+	return db.loadFile()
+}
+
+// loadFile reads the database file into memory (caller must hold the file lock)
+func (db *Database) loadFile() error {
 	file, err := os.Open(db.path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -350,37 +876,73 @@ func (db *Database) load() error {
 	// Read and verify magic header
 	var magic uint32
 	if err := binary.Read(file, binary.LittleEndian, &magic); err != nil {
-		return fmt.Errorf("failed to read magic: %w", err)
+		return fmt.Errorf("%w: failed to read magic: %w", ErrCorrupt, err)
 	}
-	if magic != 0x5A4F494E { // "ZOIN"
-		return fmt.Errorf("invalid database format")
+	if magic != databaseMagic {
+		return fmt.Errorf("%w: invalid database format", ErrCorrupt)
 	}
 
 	// Read version
 	var version uint32
 	if err := binary.Read(file, binary.LittleEndian, &version); err != nil {
-		return fmt.Errorf("failed to read version: %w", err)
+		return fmt.Errorf("%w: failed to read version: %w", ErrCorrupt, err)
 	}
-	if version != 1 {
-		return fmt.Errorf("unsupported database version: %d", version)
+	if version < 1 || version > databaseVersion {
+		return &VersionError{Version: version}
 	}
 
 	// Read number of entries
 	var entryCount uint32
 	if err := binary.Read(file, binary.LittleEndian, &entryCount); err != nil {
-		return fmt.Errorf("failed to read entry count: %w", err)
+		return fmt.Errorf("%w: failed to read entry count: %w", ErrCorrupt, err)
 	}
 
 	// Read entries
 	db.entries = make(map[string]*DirectoryEntry, entryCount)
+	db.index = nil
 	for i := uint32(0); i < entryCount; i++ {
-		entry, err := readEntry(file)
+		entry, err := readEntry(file, version)
 		if err != nil {
-			return fmt.Errorf("failed to read entry %d: %w", i, err)
+			return fmt.Errorf("%w: failed to read entry %d: %w", ErrCorrupt, i, err)
 		}
 		db.entries[entry.Path] = entry
 	}
 
+	db.evicted = 0
+	if version >= 4 {
+		if err := binary.Read(file, binary.LittleEndian, &db.evicted); err != nil {
+			return fmt.Errorf("%w: failed to read eviction count: %w", ErrCorrupt, err)
+		}
+	}
+
+	db.transitions = make(map[string][]*Transition)
+	if version >= 7 {
+		if db.transitions, err = readTransitions(file); err != nil {
+			return fmt.Errorf("%w: failed to read transitions: %w", ErrCorrupt, err)
+		}
+	}
+
+	db.choices = make(map[string][]*Transition)
+	if version >= 9 {
+		if db.choices, err = readTransitions(file); err != nil {
+			return fmt.Errorf("%w: failed to read choices: %w", ErrCorrupt, err)
+		}
+	}
+
+	db.spoolReplayed = ""
+	if version >= 10 {
+		if db.spoolReplayed, err = readString(file); err != nil {
+			return fmt.Errorf("%w: failed to read spool ID: %w", ErrCorrupt, err)
+		}
+	}
+
+	db.imports = nil
+	if version >= 11 {
+		if db.imports, err = readImports(file); err != nil {
+			return fmt.Errorf("%w: failed to read import markers: %w", ErrCorrupt, err)
+		}
+	}
+
 	return nil
 }
 
@@ -406,11 +968,41 @@ func writeEntry(w io.Writer, entry *DirectoryEntry) error {
 		return err
 	}
 
+	// Version 2: entry source
+	if err := binary.Write(w, binary.LittleEndian, uint8(entry.Source)); err != nil {
+		return err
+	}
+
+	// Version 3: entry flags
+	if err := binary.Write(w, binary.LittleEndian, uint8(entry.Flags)); err != nil {
+		return err
+	}
+
+	// Version 5: tags
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(entry.Tags))); err != nil {
+		return err
+	}
+	for _, tag := range entry.Tags {
+		if err := writeString(w, tag); err != nil {
+			return err
+		}
+	}
+
+	// Version 6: note
+	if err := writeString(w, entry.Note); err != nil {
+		return err
+	}
+
+	// Version 8: hour-of-week histogram
+	if err := writeTimeSlots(w, entry.TimeSlots); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// readEntry reads a single entry from the file
-func readEntry(r io.Reader) (*DirectoryEntry, error) {
+// readEntry reads a single entry written with the given format version
+func readEntry(r io.Reader, version uint32) (*DirectoryEntry, error) {
 	// Read path length
 	var pathLen uint32
 	if err := binary.Read(r, binary.LittleEndian, &pathLen); err != nil {
@@ -438,16 +1030,83 @@ func readEntry(r io.Reader) (*DirectoryEntry, error) {
 		return nil, err
 	}
 
+	if version >= 2 {
+		var source uint8
+		if err := binary.Read(r, binary.LittleEndian, &source); err != nil {
+			return nil, err
+		}
+		entry.Source = EntrySource(source)
+	}
+
+	if version >= 3 {
+		var flags uint8
+		if err := binary.Read(r, binary.LittleEndian, &flags); err != nil {
+			return nil, err
+		}
+		entry.Flags = EntryFlags(flags)
+	}
+
+	if version >= 5 {
+		var tagCount uint32
+		if err := binary.Read(r, binary.LittleEndian, &tagCount); err != nil {
+			return nil, err
+		}
+		for i := uint32(0); i < tagCount; i++ {
+			tag, err := readString(r)
+			if err != nil {
+				return nil, err
+			}
+			entry.Tags = append(entry.Tags, tag)
+		}
+	}
+
+	if version >= 6 {
+		note, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		entry.Note = note
+	}
+
+	if version >= 8 {
+		slots, err := readTimeSlots(r)
+		if err != nil {
+			return nil, err
+		}
+		entry.TimeSlots = slots
+	}
+
 	return entry, nil
 }
 
-// calculateFrecency computes the frecency score for an entry
-func calculateFrecency(entry *DirectoryEntry) float64 {
+// writeString writes a length-prefixed string
+func writeString(w io.Writer, s string) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// readString reads a length-prefixed string
+func readString(r io.Reader) (string, error) {
+	var length uint32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return "", err
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// calculateFrecency computes the frecency score for an entry at a time
+func calculateFrecency(entry *DirectoryEntry, now int64) float64 {
 	// Simple frecency algorithm:
 	// Score = frequency * recency_factor
 	// Recency factor decreases exponentially with age
 
-	now := time.Now().Unix()
 	age := float64(now - entry.LastVisited)
 
 	// Convert age from seconds to days
@@ -465,7 +1124,12 @@ func calculateFrecency(entry *DirectoryEntry) float64 {
 		}
 	}
 
-	return float64(entry.VisitCount) * recencyFactor
+	score := float64(entry.VisitCount) * recencyFactor
+	if entry.Source == SourceScan {
+		score *= scanFrecencyWeight
+	}
+
+	return score
 }
 
 // fuzzyMatch implements an fzf-inspired fuzzy matching algorithm
@@ -477,17 +1141,34 @@ func fuzzyMatch(text, pattern string) int {
 	// Use only the basename for matching (like most directory jumpers)
 	text = filepath.Base(text)
 
-	// Convert to lowercase for case-insensitive matching
-	textLower := strings.ToLower(text)
-	patternLower := strings.ToLower(pattern)
+	// Smart case: match case-insensitively unless the pattern has uppercase
+	textCmp, patternCmp := text, pattern
+	if !hasUpper(pattern) {
+		textCmp = strings.ToLower(text)
+		patternCmp = strings.ToLower(pattern)
+	}
 
 	// Check if we can match all pattern characters
-	if !canMatch(textLower, patternLower) {
+	if !canMatch(textCmp, patternCmp) {
 		return 0
 	}
 
-	// Calculate detailed score
-	return calculateFuzzyScore(text, textLower, pattern, patternLower)
+	// Calculate detailed score, preferring a match on word initials
+	score := calculateFuzzyScore(text, textCmp, pattern, patternCmp)
+	if acronym := calculateAcronymScore(text, textCmp, patternCmp); acronym > score {
+		score = acronym
+	}
+	return score
+}
+
+// hasUpper reports whether s contains an uppercase letter
+func hasUpper(s string) bool {
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
 }
 
 // canMatch checks if all characters in pattern exist in text in order
@@ -510,25 +1191,28 @@ func canMatch(text, pattern string) bool {
 	return true
 }
 
-// calculateFuzzyScore computes a detailed fuzzy match score
+// Bonus constants (similar to fzf)
+const (
+	scoreMatch            = 16
+	scoreCaseMatch        = 1
+	scoreConsecutive      = 32
+	scoreWordBoundary     = 8
+	scoreFirstCharBonus   = 32
+	scoreAcronym          = 16
+	penaltyLeading        = -2
+	penaltyMaxLeading     = -12
+	penaltyNonConsecutive = -1
+)
+
+// calculateFuzzyScore computes a detailed fuzzy match score. textLower and
+// patternLower are the forms compared: lowercased, or as typed when matching
+// case-sensitively.
 func calculateFuzzyScore(text, textLower, pattern, patternLower string) int {
 	score := 0
 	patternIdx := 0
 	textIdx := 0
 	consecutiveCount := 0
 
-	// Bonus constants (similar to fzf)
-	const (
-		scoreMatch            = 16
-		scoreCaseMatch        = 1
-		scoreConsecutive      = 32
-		scoreWordBoundary     = 8
-		scoreFirstCharBonus   = 32
-		penaltyLeading        = -2
-		penaltyMaxLeading     = -12
-		penaltyNonConsecutive = -1
-	)
-
 	// Track leading penalty
 	leadingPenalty := 0
 
@@ -556,8 +1240,9 @@ func calculateFuzzyScore(text, textLower, pattern, patternLower string) int {
 			}
 			consecutiveCount++
 
-			// Word boundary bonus (after slash, dash, underscore, space, or at start)
-			if textIdx == 0 || isWordBoundary(rune(text[textIdx-1])) {
+			// Word boundary bonus (at start, after a separator, or at a
+			// camelCase or letter/digit transition)
+			if isBoundaryAt(text, textIdx) {
 				currentScore += scoreWordBoundary
 			}
 
@@ -595,7 +1280,66 @@ func calculateFuzzyScore(text, textLower, pattern, patternLower string) int {
 	return score
 }
 
+// calculateAcronymScore scores a pattern matched against the initials of
+// the words in text ("gcp" on "google-cloud-platform", "ma" on "MyApp").
+// It returns 0 unless every pattern character matches an initial.
+func calculateAcronymScore(text, textCmp, patternCmp string) int {
+	if len(patternCmp) < 2 {
+		return 0
+	}
+
+	var initials []byte
+	for i := 0; i < len(text); i++ {
+		if isBoundaryAt(text, i) && !isWordBoundary(rune(text[i])) {
+			initials = append(initials, textCmp[i])
+		}
+	}
+	if len(initials) < len(patternCmp) || !canMatch(string(initials), patternCmp) {
+		return 0
+	}
+
+	// Every character is a boundary match; matching initials in sequence
+	// counts as half a consecutive match
+	score := scoreFirstCharBonus
+	for i := 0; i < len(patternCmp); i++ {
+		score += scoreMatch + scoreWordBoundary
+		if i > 0 {
+			score += scoreAcronym
+		}
+	}
+
+	// Prefer acronyms covering more of the words
+	score += int(float64(len(patternCmp)) / float64(len(initials)) * 25)
+
+	return score
+}
+
 // isWordBoundary checks if a character is a word boundary
 func isWordBoundary(char rune) bool {
 	return char == '/' || char == '-' || char == '_' || char == ' ' || char == '.'
 }
+
+// isBoundaryAt reports whether a word starts at text[i]: at the start, after
+// a separator, at a camelCase transition ("myApp"), at the last capital of an
+// acronym followed by lowercase ("HTTPServer"), or where letters and digits
+// meet ("svc2", "2fa")
+func isBoundaryAt(text string, i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	prev, cur := rune(text[i-1]), rune(text[i])
+	switch {
+	case isWordBoundary(prev):
+		return true
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return true
+	case unicode.IsUpper(prev) && unicode.IsUpper(cur):
+		return i+1 < len(text) && unicode.IsLower(rune(text[i+1]))
+	case unicode.IsLetter(prev) && unicode.IsDigit(cur):
+		return true
+	case unicode.IsDigit(prev) && unicode.IsLetter(cur):
+		return true
+	}
+	return false
+}
@@ -1,6 +1,10 @@
 package database
 
 import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -79,7 +83,11 @@ func TestDatabaseCleanup(t *testing.T) {
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "test.db")
 
-	config := DatabaseConfig{Path: dbPath}
+	existingDir := "/home/user/exists"
+	nonExistingDir := "/home/user/does/not/exist"
+	fsys := newFakeFS(existingDir)
+
+	config := DatabaseConfig{Path: dbPath, FS: fsys, Mounts: &MountTable{}}
 	db, err := New(config)
 	if err != nil {
 		t.Fatalf("Failed to create database: %v", err)
@@ -87,9 +95,6 @@ func TestDatabaseCleanup(t *testing.T) {
 	defer db.Close()
 
 	// Add visits to existing and non-existing directories
-	existingDir := tempDir // This exists
-	nonExistingDir := "/this/path/does/not/exist"
-
 	if err := db.AddVisit(existingDir); err != nil {
 		t.Errorf("Failed to add visit for existing dir: %v", err)
 	}
@@ -116,21 +121,28 @@ func TestDatabaseCleanup(t *testing.T) {
 	if len(results) > 0 {
 		t.Error("Expected no results for non-existing directory after cleanup")
 	}
+
+	// Deleting the other directory later gets it cleaned up too
+	fsys.RemoveAll("/home/user")
+	if removed, _ := db.CleanupMissing(); removed != 1 || db.Len() != 0 {
+		t.Errorf("Expected the deleted directory to be removed, removed %d, %d left", removed, db.Len())
+	}
 }
 
 func TestFrecencyCalculation(t *testing.T) {
-	now := time.Now().Unix()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC).Unix()
+	day := int64(24 * 60 * 60)
 
 	tests := []struct {
 		name     string
 		entry    *DirectoryEntry
-		expected float64 // Approximate expected value
+		expected float64
 	}{
 		{
 			name: "High frequency, recent",
 			entry: &DirectoryEntry{
 				VisitCount:  10,
-				LastVisited: now, // Now
+				LastVisited: now,
 			},
 			expected: 10.0, // Full score
 		},
@@ -142,24 +154,340 @@ func TestFrecencyCalculation(t *testing.T) {
 			},
 			expected: 2.0,
 		},
+		{
+			name: "High frequency, one half-life old",
+			entry: &DirectoryEntry{
+				VisitCount:  10,
+				LastVisited: now - 30*day,
+			},
+			expected: 5.0,
+		},
 		{
 			name: "High frequency, old",
 			entry: &DirectoryEntry{
 				VisitCount:  10,
-				LastVisited: now - (60 * 24 * 60 * 60), // 60 days ago
+				LastVisited: now - 60*day,
+			},
+			expected: 2.5, // Halved twice
+		},
+		{
+			name: "Ancient",
+			entry: &DirectoryEntry{
+				VisitCount:  10,
+				LastVisited: now - 3650*day,
 			},
-			expected: 2.5, // Should be much lower due to age
+			expected: 0.1, // Decay stops at the minimum factor
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			score := calculateFrecency(tt.entry)
+			score := calculateFrecency(tt.entry, now)
 
-			// Allow some tolerance in the comparison
-			if score < tt.expected*0.8 || score > tt.expected*1.2 {
-				t.Errorf("Frecency score %f not close to expected %f", score, tt.expected)
+			if math.Abs(score-tt.expected) > 1e-9 {
+				t.Errorf("Frecency score %f, expected %f", score, tt.expected)
 			}
 		})
 	}
 }
+
+func TestRankingOverMonths(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	old, current := "/home/user/old-job", "/home/user/new-job"
+	fsys := newFakeFS(old, current)
+
+	config := DatabaseConfig{
+		Path:   filepath.Join(t.TempDir(), "test.db"),
+		Clock:  clock.Now,
+		FS:     fsys,
+		Mounts: &MountTable{},
+	}
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	top := func() string {
+		t.Helper()
+		results, err := db.Query("job", 1)
+		if err != nil || len(results) != 1 {
+			t.Fatalf("Query = %v, %v", results, err)
+		}
+		return results[0].Path
+	}
+
+	// Three months of daily visits to the old project
+	for day := 0; day < 90; day++ {
+		db.AddVisit(old)
+		clock.Advance(24 * time.Hour)
+	}
+	if got := top(); got != old {
+		t.Fatalf("After three months top result is %s, want %s", got, old)
+	}
+
+	// Switching projects: a few visits aren't enough to overtake it yet...
+	for day := 0; day < 5; day++ {
+		db.AddVisit(current)
+		clock.Advance(24 * time.Hour)
+	}
+	if got := top(); got != old {
+		t.Errorf("After five days top result is %s, want %s", got, old)
+	}
+
+	// ...but a few months of decay are
+	for day := 0; day < 90; day++ {
+		db.AddVisit(current)
+		clock.Advance(24 * time.Hour)
+	}
+	if got := top(); got != current {
+		t.Errorf("After three more months top result is %s, want %s", got, current)
+	}
+	// Unvisited for over three half-lives, its 90 visits count for less than 90/8
+	entry, _ := db.Get(old)
+	if frecency := entry.FrecencyAt(clock.Now()); frecency >= 90.0/8 {
+		t.Errorf("Expected the old project to decay, frecency is %f", frecency)
+	}
+
+	// Once the old project is deleted cleanup forgets it
+	fsys.RemoveAll(old)
+	if removed, _ := db.CleanupMissing(); removed != 1 {
+		t.Errorf("Expected 1 removed entry, got %d", removed)
+	}
+	if _, exists := db.Get(old); exists {
+		t.Error("Expected the deleted project to be forgotten")
+	}
+}
+
+func TestAddScanned(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test.db")
+
+	config := DatabaseConfig{Path: dbPath}
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.AddVisit("/home/user/visited-app"); err != nil {
+		t.Fatalf("Failed to add visit: %v", err)
+	}
+
+	inserted, err := db.AddScanned("/home/user/scanned-app")
+	if err != nil || !inserted {
+		t.Fatalf("Expected scanned entry to be inserted, got %v (%v)", inserted, err)
+	}
+
+	// Existing entries are never overwritten by a scan
+	inserted, err = db.AddScanned("/home/user/visited-app")
+	if err != nil || inserted {
+		t.Errorf("Expected existing entry to be left alone, got %v (%v)", inserted, err)
+	}
+
+	// Scanned entries rank below visited ones
+	results, err := db.Query("app", 10)
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if len(results) != 2 || results[0].Path != "/home/user/visited-app" {
+		t.Fatalf("Expected visited entry first, got %v", results)
+	}
+	if results[1].Source != SourceScan {
+		t.Errorf("Expected scan source, got %s", results[1].Source)
+	}
+
+	// Source survives a save/load round trip
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+	db2, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	results, _ = db2.Query("scanned", 10)
+	if len(results) != 1 || results[0].Source != SourceScan {
+		t.Fatalf("Expected scan source after reload, got %v", results)
+	}
+
+	// A real visit promotes the entry
+	if err := db2.AddVisit("/home/user/scanned-app"); err != nil {
+		t.Fatalf("Failed to add visit: %v", err)
+	}
+	results, _ = db2.Query("scanned", 10)
+	if results[0].Source != SourceVisit || results[0].VisitCount != 2 {
+		t.Errorf("Expected promoted entry with 2 visits, got %s with %d",
+			results[0].Source, results[0].VisitCount)
+	}
+}
+
+func TestAddVisitAt(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test.db")
+
+	db, err := New(DatabaseConfig{Path: dbPath})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	path := "/home/user/imported"
+	for _, ts := range []int64{2000, 1000, 3000} {
+		if err := db.AddVisitAt(path, ts); err != nil {
+			t.Fatalf("Failed to add visit: %v", err)
+		}
+	}
+
+	results, err := db.Query("imported", 10)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d (%v)", len(results), err)
+	}
+
+	entry := results[0]
+	if entry.VisitCount != 3 || entry.FirstVisited != 1000 || entry.LastVisited != 3000 {
+		t.Errorf("Expected 3 visits from 1000 to 3000, got %d from %d to %d",
+			entry.VisitCount, entry.FirstVisited, entry.LastVisited)
+	}
+}
+
+func TestMovePrefix(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test.db")
+
+	db, err := New(DatabaseConfig{Path: dbPath})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	db.AddVisitAt("/home/user/code", 100)
+	db.AddVisitAt("/home/user/code/api", 200)
+	db.AddVisitAt("/home/user/code/api", 300)
+	db.AddVisitAt("/home/user/codebase", 400) // shares the string prefix only
+	db.AddVisitAt("/home/user/work/api", 50)  // merge target
+
+	moved, merged, err := db.MovePrefix("/home/user/code/", "/home/user/work")
+	if err != nil {
+		t.Fatalf("MovePrefix failed: %v", err)
+	}
+	if moved != 2 || merged != 1 {
+		t.Errorf("Expected 2 moved and 1 merged, got %d and %d", moved, merged)
+	}
+
+	entries := make(map[string]*DirectoryEntry)
+	all, _ := db.GetAll()
+	for _, entry := range all {
+		entries[entry.Path] = entry
+	}
+
+	if len(entries) != 3 {
+		t.Errorf("Expected 3 entries after move, got %d", len(entries))
+	}
+	if _, ok := entries["/home/user/codebase"]; !ok {
+		t.Error("Expected /home/user/codebase to be left alone")
+	}
+	if _, ok := entries["/home/user/work"]; !ok {
+		t.Error("Expected /home/user/code to become /home/user/work")
+	}
+
+	api := entries["/home/user/work/api"]
+	if api == nil {
+		t.Fatal("Expected merged /home/user/work/api entry")
+	}
+	if api.VisitCount != 3 || api.FirstVisited != 50 || api.LastVisited != 300 {
+		t.Errorf("Expected 3 visits from 50 to 300, got %d from %d to %d",
+			api.VisitCount, api.FirstVisited, api.LastVisited)
+	}
+
+	if _, _, err := db.MovePrefix("/home/user/work", "/home/user/work/nested"); err == nil {
+		t.Error("Expected error moving a prefix into itself")
+	}
+}
+
+func TestLoadVersion1Database(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test.db")
+
+	// Hand-write a version 1 file: header, count, then path/visits/last/first
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint32(databaseMagic))
+	binary.Write(&buf, binary.LittleEndian, uint32(1))
+	binary.Write(&buf, binary.LittleEndian, uint32(1))
+	path := "/home/user/legacy"
+	binary.Write(&buf, binary.LittleEndian, uint32(len(path)))
+	buf.WriteString(path)
+	binary.Write(&buf, binary.LittleEndian, uint32(7))
+	binary.Write(&buf, binary.LittleEndian, int64(2000))
+	binary.Write(&buf, binary.LittleEndian, int64(1000))
+	if err := os.WriteFile(dbPath, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write legacy database: %v", err)
+	}
+
+	db, err := New(DatabaseConfig{Path: dbPath})
+	if err != nil {
+		t.Fatalf("Failed to load legacy database: %v", err)
+	}
+
+	entries, _ := db.GetAll()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Path != path || entry.VisitCount != 7 || entry.LastVisited != 2000 ||
+		entry.FirstVisited != 1000 || entry.Source != SourceVisit || entry.Flags != 0 {
+		t.Errorf("Unexpected legacy entry: %+v", entry)
+	}
+}
+
+func TestPinnedEntriesRankFirst(t *testing.T) {
+	tempDir := t.TempDir()
+	config := DatabaseConfig{Path: filepath.Join(tempDir, "test.db")}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	// The busy entry has far more visits and is a better fuzzy match
+	for i := 0; i < 20; i++ {
+		db.AddVisit("/home/user/api")
+	}
+	db.AddVisit("/home/user/code/monorepo/services/api-gateway")
+
+	if err := db.SetPinned("/home/user/untracked", true); err == nil {
+		t.Error("Expected an error pinning an untracked path")
+	}
+	if err := db.SetPinned("/home/user/code/monorepo/services/api-gateway", true); err != nil {
+		t.Fatalf("Failed to pin: %v", err)
+	}
+
+	results, _ := db.Query("api", 10)
+	if len(results) != 2 || results[0].Path != "/home/user/code/monorepo/services/api-gateway" {
+		t.Fatalf("Expected pinned entry first, got %v", results)
+	}
+
+	// Pins only apply to entries that match the query
+	db.AddVisit("/home/user/docs")
+	results, _ = db.Query("docs", 10)
+	if len(results) != 1 || results[0].IsPinned() {
+		t.Errorf("Expected only the unpinned match, got %v", results)
+	}
+
+	// Pins survive a save/load round trip and can be removed
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+	db2, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	results, _ = db2.Query("", 10)
+	if len(results) != 3 || !results[0].IsPinned() {
+		t.Fatalf("Expected pinned entry first after reload, got %v", results)
+	}
+
+	db2.SetPinned(results[0].Path, false)
+	results, _ = db2.Query("api", 10)
+	if results[0].Path != "/home/user/api" {
+		t.Errorf("Expected frecency order after unpinning, got %s first", results[0].Path)
+	}
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned for directories that aren't in the database
+	ErrNotFound = errors.New("directory not in database")
+	// ErrCorrupt is returned when the database file can't be parsed
+	ErrCorrupt = errors.New("database is corrupt")
+	// ErrVersionMismatch is returned for database files in a format this
+	// version can't read, usually because a newer zoink wrote them
+	ErrVersionMismatch = errors.New("unsupported database version")
+	// ErrLocked is returned when another process holds the database lock
+	// for longer than the lock timeout
+	ErrLocked = errors.New("database is locked")
+	// ErrReadOnly is returned when changing a database opened read-only
+	ErrReadOnly = errors.New("database is read-only")
+)
+
+// VersionError describes a database file in an unsupported format. It
+// matches ErrVersionMismatch.
+type VersionError struct {
+	Version uint32
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("unsupported database version: %d (this zoink reads up to %d)", e.Version, databaseVersion)
+}
+
+func (e *VersionError) Is(target error) bool {
+	return target == ErrVersionMismatch
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+func TestLoadErrors(t *testing.T) {
+	header := func(version uint32) []byte {
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.LittleEndian, uint32(databaseMagic))
+		binary.Write(&buf, binary.LittleEndian, version)
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name     string
+		contents []byte
+		expected error
+	}{
+		{"garbage", []byte("not a database"), ErrCorrupt},
+		{"truncated", append(header(databaseVersion), 5, 0, 0, 0), ErrCorrupt},
+		{"newer version", append(header(databaseVersion+1), 0, 0, 0, 0), ErrVersionMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.db")
+			if err := os.WriteFile(path, tt.contents, 0644); err != nil {
+				t.Fatalf("Failed to write database: %v", err)
+			}
+
+			_, err := New(DatabaseConfig{Path: path})
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("New returned %v, want %v", err, tt.expected)
+			}
+		})
+	}
+
+	// The version error says which version was found
+	path := filepath.Join(t.TempDir(), "test.db")
+	os.WriteFile(path, header(99), 0644)
+	var versionErr *VersionError
+	if _, err := New(DatabaseConfig{Path: path}); !errors.As(err, &versionErr) || versionErr.Version != 99 {
+		t.Errorf("Expected a VersionError for version 99, got %v", err)
+	}
+}
+
+func TestNotFoundErrors(t *testing.T) {
+	db, err := New(DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	errs := map[string]error{
+		"SetPinned":    db.SetPinned("/missing", true),
+		"SetNote":      db.SetNote("/missing", "note"),
+		"AddTags":      db.AddTags("/missing", "tag"),
+		"RecordChoice": db.RecordChoice("query", "/missing"),
+	}
+	for name, err := range errs {
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s returned %v, want ErrNotFound", name, err)
+		}
+	}
+}
+
+func TestLockedError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := New(DatabaseConfig{Path: path})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	db.lockTimeout = 50 * time.Millisecond
+
+	held := flock.New(path + ".lock")
+	if err := held.Lock(); err != nil {
+		t.Fatalf("Failed to take the lock: %v", err)
+	}
+	defer held.Unlock()
+
+	db.AddVisit("/home/user/project")
+	if err := db.Save(); !errors.Is(err, ErrLocked) {
+		t.Errorf("Save returned %v, want ErrLocked", err)
+	}
+	if err := db.Update(func(tx *Tx) error { return nil }); !errors.Is(err, ErrLocked) {
+		t.Errorf("Update returned %v, want ErrLocked", err)
+	}
+}
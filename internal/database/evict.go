@@ -0,0 +1,88 @@
+package database
+
+import (
+	"container/heap"
+)
+
+// evictionCandidate is an entry considered for eviction with its score
+type evictionCandidate struct {
+	path        string
+	frecency    float64
+	lastVisited int64
+}
+
+// evictionHeap keeps the k best eviction candidates with the least
+// deserving one (highest frecency) on top so it can be replaced
+type evictionHeap []evictionCandidate
+
+// keptBefore reports whether a deserves to stay more than b
+func (a evictionCandidate) keptBefore(b evictionCandidate) bool {
+	if a.frecency != b.frecency {
+		return a.frecency > b.frecency
+	}
+	// Older entries go first among equal scores, then by path
+	if a.lastVisited != b.lastVisited {
+		return a.lastVisited > b.lastVisited
+	}
+	return a.path < b.path
+}
+
+func (h evictionHeap) Len() int           { return len(h) }
+func (h evictionHeap) Less(i, j int) bool { return h[i].keptBefore(h[j]) }
+func (h evictionHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *evictionHeap) Push(x any)        { *h = append(*h, x.(evictionCandidate)) }
+func (h *evictionHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// evictOverflow removes the lowest-frecency entries beyond the configured
+// cap and returns how many were removed. Pinned entries are never evicted,
+// so the cap can be exceeded by them (caller must hold lock)
+func (db *Database) evictOverflow() int {
+	if db.maxEntries <= 0 || len(db.entries) <= db.maxEntries {
+		return 0
+	}
+	excess := len(db.entries) - db.maxEntries
+
+	// Select the excess lowest-scoring entries in O(n log excess)
+	now := db.clock().Unix()
+	candidates := make(evictionHeap, 0, excess)
+	for path, entry := range db.entries {
+		if entry.IsPinned() {
+			continue
+		}
+		candidate := evictionCandidate{
+			path:        path,
+			frecency:    calculateFrecency(entry, now),
+			lastVisited: entry.LastVisited,
+		}
+		if len(candidates) < excess {
+			heap.Push(&candidates, candidate)
+			continue
+		}
+		top := candidates[0]
+		if top.keptBefore(candidate) {
+			candidates[0] = candidate
+			heap.Fix(&candidates, 0)
+		}
+	}
+
+	for _, candidate := range candidates {
+		db.deleteEntry(candidate.path)
+	}
+	db.evicted += uint64(len(candidates))
+
+	return len(candidates)
+}
+
+// EvictedCount returns how many entries have been evicted because the
+// database exceeded max_entries, over its whole lifetime
+func (db *Database) EvictedCount() uint64 {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	return db.evicted
+}
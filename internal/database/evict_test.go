@@ -0,0 +1,108 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEvictionOnSave(t *testing.T) {
+	tempDir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	config := DatabaseConfig{Path: filepath.Join(tempDir, "test.db"), MaxEntries: 5, Clock: clock.Now}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	// Ten entries with increasing frecency: visit count i+1
+	for i := 0; i < 10; i++ {
+		path := fmt.Sprintf("/home/user/project%d", i)
+		for j := 0; j <= i; j++ {
+			db.AddVisit(path)
+		}
+	}
+
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+
+	entries, _ := db.GetAll()
+	if len(entries) != 5 {
+		t.Fatalf("Expected 5 entries after eviction, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.VisitCount < 6 {
+			t.Errorf("Expected only the 5 most frecent entries, found %s with %d visits",
+				entry.Path, entry.VisitCount)
+		}
+	}
+
+	// The eviction counter is persisted and accumulates
+	db2, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	if db2.EvictedCount() != 5 {
+		t.Errorf("Expected 5 evictions after reload, got %d", db2.EvictedCount())
+	}
+
+	db2.AddVisitAt("/home/user/stale", clock.Now().AddDate(-1, 0, 0).Unix())
+	db2.Save()
+	if db2.EvictedCount() != 6 {
+		t.Errorf("Expected 6 evictions, got %d", db2.EvictedCount())
+	}
+	results, _ := db2.Query("stale", 10)
+	if len(results) != 0 {
+		t.Error("Expected the stale entry to be evicted first")
+	}
+}
+
+func TestNoEvictionWithoutCap(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := New(DatabaseConfig{Path: filepath.Join(tempDir, "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		db.AddVisit(fmt.Sprintf("/home/user/project%d", i))
+	}
+	db.Save()
+
+	entries, _ := db.GetAll()
+	if len(entries) != 100 || db.EvictedCount() != 0 {
+		t.Errorf("Expected no eviction, got %d entries and %d evictions", len(entries), db.EvictedCount())
+	}
+}
+
+func TestPinnedEntriesAreNotEvicted(t *testing.T) {
+	tempDir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	db, err := New(DatabaseConfig{Path: filepath.Join(tempDir, "test.db"), MaxEntries: 2, Clock: clock.Now})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	// The pinned entry was last visited a year before the others
+	db.AddVisit("/home/user/pinned")
+	clock.Advance(365 * 24 * time.Hour)
+	for i := 0; i < 3; i++ {
+		db.AddVisit(fmt.Sprintf("/home/user/project%d", i))
+	}
+	if err := db.SetPinned("/home/user/pinned", true); err != nil {
+		t.Fatalf("Failed to pin: %v", err)
+	}
+	db.Save()
+
+	entries, _ := db.GetAll()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries after eviction, got %d", len(entries))
+	}
+	results, _ := db.Query("pinned", 10)
+	if len(results) != 1 {
+		t.Error("Expected the pinned entry to survive eviction")
+	}
+}
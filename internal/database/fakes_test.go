@@ -0,0 +1,79 @@
+package database
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeClock is a settable clock for deterministic tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// Advance moves the clock forward
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// fakeFS is an in-memory file system holding only directories
+type fakeFS struct {
+	mutex sync.RWMutex
+	dirs  map[string]bool
+}
+
+// newFakeFS returns a file system with the given directories and their
+// ancestors
+func newFakeFS(dirs ...string) *fakeFS {
+	f := &fakeFS{dirs: map[string]bool{"/": true}}
+	for _, dir := range dirs {
+		f.Mkdir(dir)
+	}
+	return f
+}
+
+// Mkdir creates a directory and its missing ancestors
+func (f *fakeFS) Mkdir(dir string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for dir = filepath.Clean(dir); !f.dirs[dir]; dir = filepath.Dir(dir) {
+		f.dirs[dir] = true
+	}
+}
+
+// RemoveAll removes a directory and everything below it
+func (f *fakeFS) RemoveAll(dir string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	dir = filepath.Clean(dir)
+	for path := range f.dirs {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			delete(f.dirs, path)
+		}
+	}
+}
+
+func (f *fakeFS) Stat(name string) (os.FileInfo, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	if !f.dirs[filepath.Clean(name)] {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return fakeDirInfo(filepath.Base(name)), nil
+}
+
+// fakeDirInfo describes a directory of a fakeFS
+type fakeDirInfo string
+
+func (d fakeDirInfo) Name() string       { return string(d) }
+func (d fakeDirInfo) Size() int64        { return 0 }
+func (d fakeDirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0755 }
+func (d fakeDirInfo) ModTime() time.Time { return time.Time{} }
+func (d fakeDirInfo) IsDir() bool        { return true }
+func (d fakeDirInfo) Sys() any           { return nil }
@@ -0,0 +1,297 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Predicate reports whether an entry matches a filter
+type Predicate func(entry *DirectoryEntry) bool
+
+// All matches entries accepted by every predicate (and by default, none given)
+func All(predicates ...Predicate) Predicate {
+	return func(entry *DirectoryEntry) bool {
+		for _, predicate := range predicates {
+			if !predicate(entry) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any matches entries accepted by at least one predicate
+func Any(predicates ...Predicate) Predicate {
+	return func(entry *DirectoryEntry) bool {
+		for _, predicate := range predicates {
+			if predicate(entry) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not inverts a predicate
+func Not(predicate Predicate) Predicate {
+	return func(entry *DirectoryEntry) bool {
+		return !predicate(entry)
+	}
+}
+
+// OlderThan matches entries not visited within the given duration before now
+func OlderThan(age time.Duration, now time.Time) Predicate {
+	cutoff := now.Add(-age).Unix()
+	return func(entry *DirectoryEntry) bool {
+		return entry.LastVisited < cutoff
+	}
+}
+
+// VisitsBelow matches entries visited fewer than n times
+func VisitsBelow(n uint32) Predicate {
+	return func(entry *DirectoryEntry) bool {
+		return entry.VisitCount < n
+	}
+}
+
+// Under matches entries at or below the given directory
+func Under(prefix string) Predicate {
+	prefix = filepath.Clean(prefix)
+	return func(entry *DirectoryEntry) bool {
+		return hasPathPrefix(entry.Path, prefix)
+	}
+}
+
+// Matching matches entries whose full path matches a glob pattern. Patterns
+// without a separator are matched against the basename instead.
+func Matching(pattern string) (Predicate, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	matchBase := !strings.ContainsRune(pattern, filepath.Separator)
+	return func(entry *DirectoryEntry) bool {
+		target := entry.Path
+		if matchBase {
+			target = filepath.Base(entry.Path)
+		}
+		matched, _ := filepath.Match(pattern, target)
+		return matched
+	}, nil
+}
+
+// MatchingRegexp matches entries whose full path matches an RE2 expression
+func MatchingRegexp(expr string) (Predicate, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", expr, err)
+	}
+
+	return func(entry *DirectoryEntry) bool {
+		return re.MatchString(entry.Path)
+	}, nil
+}
+
+// MatchingPathGlob matches entries whose full path matches a glob pattern.
+// '*' and '?' don't cross path separators, '**' does, and character classes
+// work as in filepath.Match.
+func MatchingPathGlob(pattern string) (Predicate, error) {
+	expr, err := globToRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	return func(entry *DirectoryEntry) bool {
+		return re.MatchString(entry.Path)
+	}, nil
+}
+
+// globToRegexp translates a path glob into an anchored regular expression
+func globToRegexp(pattern string) (string, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	separator := regexp.QuoteMeta(string(filepath.Separator))
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^" + separator + "]*")
+			}
+		case '?':
+			b.WriteString("[^" + separator + "]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("invalid pattern %q: unterminated character class", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+	return b.String(), nil
+}
+
+// FrecencyBelow matches entries whose frecency score at now is below x
+func FrecencyBelow(x float64, now time.Time) Predicate {
+	return func(entry *DirectoryEntry) bool {
+		return calculateFrecency(entry, now.Unix()) < x
+	}
+}
+
+// FrecencyAt returns the entry's frecency score at a given time
+func (e *DirectoryEntry) FrecencyAt(now time.Time) float64 {
+	return calculateFrecency(e, now.Unix())
+}
+
+// Filter returns the entries matching the predicate, sorted by path
+func (db *Database) Filter(predicate Predicate) ([]*DirectoryEntry, error) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	return db.filter(predicate), nil
+}
+
+// filter is Filter (caller must hold lock)
+func (db *Database) filter(predicate Predicate) []*DirectoryEntry {
+	var entries []*DirectoryEntry
+	for _, entry := range db.sortedEntries() {
+		if predicate(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// QueryMatching returns the entries matching the predicate ranked like an
+// empty query: pinned entries first, then by frecency
+func (db *Database) QueryMatching(predicate Predicate, opts QueryOptions) ([]*DirectoryEntry, error) {
+	matches, err := db.QueryMatchingScored(predicate, opts)
+	if err != nil {
+		return nil, err
+	}
+	return matchedEntries(matches), nil
+}
+
+// QueryMatchingScored is QueryMatching returning each entry's frecency as
+// its score
+func (db *Database) QueryMatchingScored(predicate Predicate, opts QueryOptions) ([]MatchResult, error) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	maxResults := opts.MaxResults
+	if maxResults <= 0 {
+		maxResults = len(db.entries)
+	}
+
+	now := db.clock().Unix()
+	entries := make([]*DirectoryEntry, 0, len(db.entries))
+	for _, entry := range db.entries {
+		entries = append(entries, entry)
+	}
+	return rankMatches(entries, maxResults, func(entry *DirectoryEntry) (MatchResult, bool) {
+		if !predicate(entry) {
+			return MatchResult{}, false
+		}
+		if !opts.IncludeOffline && !db.isAvailable(entry) {
+			return MatchResult{}, false
+		}
+		frecencyScore := calculateFrecency(entry, now)
+		return MatchResult{
+			Entry:         entry,
+			FrecencyScore: frecencyScore,
+			CombinedScore: frecencyScore,
+		}, true
+	}), nil
+}
+
+// sortedEntries returns every entry sorted by path (caller must hold lock)
+func (db *Database) sortedEntries() []*DirectoryEntry {
+	entries := make([]*DirectoryEntry, 0, len(db.entries))
+	for _, entry := range db.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+// RemoveMatching removes every entry matching the predicate and returns how
+// many were removed
+func (db *Database) RemoveMatching(predicate Predicate) (int, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	return db.removeMatching(predicate), nil
+}
+
+// removeMatching is RemoveMatching (caller must hold lock)
+func (db *Database) removeMatching(predicate Predicate) int {
+	removed := 0
+	for path, entry := range db.entries {
+		if predicate(entry) {
+			db.rememberRemoval(path)
+			db.deleteEntry(path)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+// ParseAge parses durations such as "90m", "36h", "180d", "6w", "3mo" or "1y".
+// Plain Go durations are accepted too.
+func ParseAge(s string) (time.Duration, error) {
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"mo", 30 * 24 * time.Hour},
+		{"d", 24 * time.Hour},
+		{"w", 7 * 24 * time.Hour},
+		{"y", 365 * 24 * time.Hour},
+	}
+
+	for _, u := range units {
+		if number, found := strings.CutSuffix(s, u.suffix); found {
+			n, err := strconv.ParseFloat(number, 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(n * float64(u.unit)), nil
+		}
+	}
+
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 36h, 180d, 6w, 3mo, 1y)", s)
+	}
+	return age, nil
+}
@@ -0,0 +1,225 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPredicates(t *testing.T) {
+	clock := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := clock.Unix()
+	day := int64(24 * 60 * 60)
+
+	fresh := &DirectoryEntry{Path: "/home/user/code/api", VisitCount: 20, LastVisited: now}
+	stale := &DirectoryEntry{Path: "/home/user/code/old-api", VisitCount: 2, LastVisited: now - 200*day}
+	tmp := &DirectoryEntry{Path: "/tmp/build-1234", VisitCount: 1, LastVisited: now - day}
+
+	matchingAPI, err := Matching("*api")
+	if err != nil {
+		t.Fatalf("Matching failed: %v", err)
+	}
+	matchingFull, err := Matching("/home/*/code/*")
+	if err != nil {
+		t.Fatalf("Matching failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		predicate Predicate
+		expected  []bool // fresh, stale, tmp
+	}{
+		{"older than 180d", OlderThan(180*24*time.Hour, clock), []bool{false, true, false}},
+		{"visits below 3", VisitsBelow(3), []bool{false, true, true}},
+		{"under /tmp", Under("/tmp"), []bool{false, false, true}},
+		{"under /home/user/code", Under("/home/user/code/"), []bool{true, true, false}},
+		{"matching basename", matchingAPI, []bool{true, true, false}},
+		{"matching full path", matchingFull, []bool{true, true, false}},
+		{"frecency below 1", FrecencyBelow(1, clock), []bool{false, true, true}},
+		{"all", All(VisitsBelow(3), Under("/home")), []bool{false, true, false}},
+		{"all of nothing", All(), []bool{true, true, true}},
+		{"any", Any(OlderThan(180*24*time.Hour, clock), Under("/tmp")), []bool{false, true, true}},
+		{"not", Not(Under("/tmp")), []bool{true, true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, entry := range []*DirectoryEntry{fresh, stale, tmp} {
+				if got := tt.predicate(entry); got != tt.expected[i] {
+					t.Errorf("%s: expected %v, got %v", entry.Path, tt.expected[i], got)
+				}
+			}
+		})
+	}
+
+	if _, err := Matching("[invalid"); err == nil {
+		t.Error("Expected error for invalid glob")
+	}
+}
+
+func TestAgePredicatesFollowDatabaseClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	db, err := New(DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db"), Clock: clock.Now})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	db.AddVisit("/home/user/old")
+	clock.Advance(200 * 24 * time.Hour)
+	db.AddVisit("/home/user/new")
+
+	for name, predicate := range map[string]Predicate{
+		"older than 180d":  OlderThan(180*24*time.Hour, db.Now()),
+		"frecency below 1": FrecencyBelow(1, db.Now()),
+	} {
+		matches, _ := db.Filter(predicate)
+		if len(matches) != 1 || matches[0].Path != "/home/user/old" {
+			t.Errorf("%s matched %v, want only /home/user/old", name, matches)
+		}
+	}
+}
+
+func TestFilterAndRemoveMatching(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := New(DatabaseConfig{Path: filepath.Join(tempDir, "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	db.AddVisit("/tmp/b")
+	db.AddVisit("/tmp/a")
+	db.AddVisit("/home/user/code")
+
+	entries, err := db.Filter(Under("/tmp"))
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Path != "/tmp/a" || entries[1].Path != "/tmp/b" {
+		t.Errorf("Expected /tmp/a and /tmp/b in order, got %v", entries)
+	}
+
+	db.StartJournal("prune")
+	removed, err := db.RemoveMatching(Under("/tmp"))
+	if err != nil || removed != 2 {
+		t.Fatalf("Expected 2 removed, got %d (%v)", removed, err)
+	}
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+
+	remaining, _ := db.GetAll()
+	if len(remaining) != 1 {
+		t.Errorf("Expected 1 remaining entry, got %d", len(remaining))
+	}
+
+	// Pruning is undoable
+	if _, err := db.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	restored, _ := db.GetAll()
+	if len(restored) != 3 {
+		t.Errorf("Expected 3 entries after undo, got %d", len(restored))
+	}
+}
+
+func TestPathMatchingPredicates(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		regex    bool
+		path     string
+		expected bool
+	}{
+		{`^/home/[^/]+/code/.*api$`, true, "/home/user/code/old-api", true},
+		{`^/home/[^/]+/code/.*api$`, true, "/home/user/code/api/v2", false},
+		{`(?i)/API`, true, "/home/user/api", true},
+		{"/home/*/code/*api", false, "/home/user/code/old-api", true},
+		{"/home/*/code/*api", false, "/home/user/code/x/api", false},
+		{"/home/**/api", false, "/home/user/code/x/api", true},
+		{"**/build-????", false, "/tmp/build-1234", true},
+		{"**/build-[0-9]*", false, "/tmp/build-1234", true},
+		{"**/build-[!0-9]*", false, "/tmp/build-1234", false},
+		{"/tmp/build.1234", false, "/tmp/build-1234", false},
+		{`/tmp/\*`, false, "/tmp/*", true},
+	}
+
+	for _, tt := range tests {
+		var predicate Predicate
+		var err error
+		if tt.regex {
+			predicate, err = MatchingRegexp(tt.pattern)
+		} else {
+			predicate, err = MatchingPathGlob(tt.pattern)
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.pattern, err)
+		}
+		if got := predicate(&DirectoryEntry{Path: tt.path}); got != tt.expected {
+			t.Errorf("%q on %s: expected %v, got %v", tt.pattern, tt.path, tt.expected, got)
+		}
+	}
+
+	if _, err := MatchingRegexp("(unclosed"); err == nil {
+		t.Error("Expected error for invalid regular expression")
+	}
+	if _, err := MatchingPathGlob("/tmp/[abc"); err == nil {
+		t.Error("Expected error for unterminated character class")
+	}
+}
+
+func TestQueryMatching(t *testing.T) {
+	db, err := New(DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		db.AddVisit("/home/user/code/web")
+	}
+	db.AddVisit("/home/user/code/api")
+	db.AddVisit("/home/user/docs")
+	db.SetPinned("/home/user/code/api", true)
+
+	under, _ := MatchingRegexp("^/home/user/code/")
+	results, err := db.QueryMatching(under, QueryOptions{})
+	if err != nil {
+		t.Fatalf("QueryMatching failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Path != "/home/user/code/api" || results[1].Path != "/home/user/code/web" {
+		t.Fatalf("Expected pinned api then web, got %v", results)
+	}
+
+	results, _ = db.QueryMatching(under, QueryOptions{MaxResults: 1})
+	if len(results) != 1 {
+		t.Errorf("Expected 1 result, got %d", len(results))
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		valid    bool
+	}{
+		{"180d", 180 * 24 * time.Hour, true},
+		{"2w", 14 * 24 * time.Hour, true},
+		{"3mo", 90 * 24 * time.Hour, true},
+		{"1y", 365 * 24 * time.Hour, true},
+		{"1.5d", 36 * time.Hour, true},
+		{"36h", 36 * time.Hour, true},
+		{"90m", 90 * time.Minute, true},
+		{"d", 0, false},
+		{"-5d", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			age, err := ParseAge(tt.input)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ParseAge(%q) error = %v, expected valid: %v", tt.input, err, tt.valid)
+			}
+			if tt.valid && age != tt.expected {
+				t.Errorf("ParseAge(%q) = %v, expected %v", tt.input, age, tt.expected)
+			}
+		})
+	}
+}
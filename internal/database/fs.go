@@ -0,0 +1,16 @@
+package database
+
+import "os"
+
+// FileSystem is the part of the file system the database looks at to tell
+// whether tracked directories still exist. Stat may be called concurrently.
+type FileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+}
+
+// osFileSystem is the operating system's file system
+type osFileSystem struct{}
+
+func (osFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
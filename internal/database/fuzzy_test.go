@@ -22,10 +22,17 @@ func TestFuzzyMatch(t *testing.T) {
 		{"/home/user/documents", "doc", true, "path prefix match"},
 		{"/very/long/path/to/project", "prj", true, "deep path match"},
 
-		// Case sensitivity
+		// Smart case: lowercase patterns ignore case, others don't
 		{"Project", "proj", true, "case insensitive"},
 		{"PROJECT", "proj", true, "case insensitive upper"},
-		{"project", "PROJ", true, "case insensitive pattern"},
+		{"project", "PROJ", false, "uppercase pattern is case sensitive"},
+		{"mail", "MA", false, "uppercase pattern rejects lowercase"},
+		{"MyApp", "MA", true, "uppercase pattern matches capitals"},
+		{"MyApp", "ma", true, "lowercase pattern matches capitals"},
+
+		// Acronyms
+		{"google-cloud-platform", "gcp", true, "acronym"},
+		{"HTTPServer", "hs", true, "acronym of caps run"},
 
 		// Word boundaries
 		{"my-awesome-project", "map", true, "word boundary match"},
@@ -73,6 +80,14 @@ func TestFuzzyMatchScoring(t *testing.T) {
 
 		// Shorter matches should score higher (more specific)
 		{"proj", "project", "proj", "shorter vs longer"},
+
+		// camelCase and digit transitions are word boundaries
+		{"MyApp", "Myapp", "ma", "camelCase boundary bonus"},
+		{"svc2", "svcx2", "svc2", "digit boundary bonus"},
+
+		// Matching word initials beats a scattered match
+		{"google-cloud-platform", "gocampus", "gcp", "acronym vs scattered"},
+		{"MyGreatApp", "mygreatapp", "mga", "camelCase acronym"},
 	}
 
 	for _, tt := range tests {
@@ -141,6 +156,49 @@ func TestIsWordBoundary(t *testing.T) {
 	}
 }
 
+func TestIsBoundaryAt(t *testing.T) {
+	tests := []struct {
+		text     string
+		index    int
+		expected bool
+		name     string
+	}{
+		{"my-app", 0, true, "start"},
+		{"my-app", 3, true, "after dash"},
+		{"my-app", 4, false, "inside word"},
+		{"myApp", 2, true, "camelCase"},
+		{"MyApp", 1, false, "after leading capital"},
+		{"HTTPServer", 3, false, "inside caps run"},
+		{"HTTPServer", 4, true, "last capital before lowercase"},
+		{"svc2", 3, true, "letter to digit"},
+		{"2fa", 1, true, "digit to letter"},
+		{"v12", 2, false, "inside number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBoundaryAt(tt.text, tt.index); got != tt.expected {
+				t.Errorf("isBoundaryAt(%q, %d) = %v, expected %v", tt.text, tt.index, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAcronymScore(t *testing.T) {
+	// Exact names still beat acronyms
+	if fuzzyMatch("gcp", "gcp") <= fuzzyMatch("google-cloud-platform", "gcp") {
+		t.Error("Expected an exact name to outrank an acronym")
+	}
+	// Single characters aren't treated as acronyms
+	if calculateAcronymScore("my-app", "my-app", "m") != 0 {
+		t.Error("Expected no acronym score for a single character")
+	}
+	// Patterns that aren't made of initials don't get one
+	if calculateAcronymScore("google-cloud", "google-cloud", "gl") != 0 {
+		t.Error("Expected no acronym score for non-initials")
+	}
+}
+
 // Benchmark the fuzzy matching performance
 func BenchmarkFuzzyMatch(b *testing.B) {
 	text := "/home/user/development/my-awesome-project"
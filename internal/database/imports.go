@@ -0,0 +1,91 @@
+package database
+
+import (
+	"encoding/binary"
+	"io"
+	"maps"
+	"sort"
+)
+
+// ImportMarker records how far a history file has been imported, so
+// importing it again only adds what was appended since
+type ImportMarker struct {
+	// Commands is how many commands of the file were imported
+	Commands int
+	// Fingerprint identifies the last imported commands, so the position
+	// can be found again after the file was truncated
+	Fingerprint string
+}
+
+// ImportMarker returns the marker saved for a history file
+func (tx *Tx) ImportMarker(source string) (ImportMarker, bool) {
+	marker, exists := tx.db.imports[source]
+	return marker, exists
+}
+
+// SetImportMarker saves the marker for a history file with the visits
+// imported from it
+func (tx *Tx) SetImportMarker(source string, marker ImportMarker) error {
+	if err := tx.checkWritable(); err != nil {
+		return err
+	}
+	imports := maps.Clone(tx.db.imports)
+	if imports == nil {
+		imports = make(map[string]ImportMarker)
+	}
+	imports[source] = marker
+	tx.db.imports = imports
+	return nil
+}
+
+// writeImports writes the import markers, sorted by file
+func writeImports(w io.Writer, imports map[string]ImportMarker) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(imports))); err != nil {
+		return err
+	}
+	sources := make([]string, 0, len(imports))
+	for source := range imports {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		marker := imports[source]
+		if err := writeString(w, source); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, uint32(marker.Commands)); err != nil {
+			return err
+		}
+		if err := writeString(w, marker.Fingerprint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readImports reads import markers written by writeImports
+func readImports(r io.Reader) (map[string]ImportMarker, error) {
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+
+	imports := make(map[string]ImportMarker, count)
+	for i := uint32(0); i < count; i++ {
+		source, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		var commands uint32
+		if err := binary.Read(r, binary.LittleEndian, &commands); err != nil {
+			return nil, err
+		}
+		fingerprint, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		imports[source] = ImportMarker{Commands: int(commands), Fingerprint: fingerprint}
+	}
+	return imports, nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportMarkers(t *testing.T) {
+	config := DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")}
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	// The cd hook already tracks the directory
+	db.AddVisitAt("/home/user/code", 1000)
+	if err := db.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Older history visits are still imported into it
+	marker := ImportMarker{Commands: 42, Fingerprint: "abc"}
+	err = db.Update(func(tx *Tx) error {
+		tx.AddVisitAt("/home/user/code", 100)
+		tx.AddVisitAt("/home/user/code", 200)
+		return tx.SetImportMarker("/home/user/.bash_history", marker)
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// A failed import leaves the marker alone
+	failed := errors.New("failed")
+	err = db.Update(func(tx *Tx) error {
+		tx.SetImportMarker("/home/user/.bash_history", ImportMarker{Commands: 50})
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Update returned %v, want the function's error", err)
+	}
+
+	reloaded, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	entry, _ := reloaded.Get("/home/user/code")
+	if entry.VisitCount != 3 || entry.FirstVisited != 100 || entry.LastVisited != 1000 {
+		t.Errorf("Expected 3 visits from 100 to 1000, got %+v", entry)
+	}
+	reloaded.View(func(tx *Tx) error {
+		if got, exists := tx.ImportMarker("/home/user/.bash_history"); !exists || got != marker {
+			t.Errorf("ImportMarker = %+v, %v, want %+v", got, exists, marker)
+		}
+		if _, exists := tx.ImportMarker("/home/user/.zsh_history"); exists {
+			t.Error("Expected no marker for a file never imported")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,256 @@
+package database
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// indexMinEntries is the database size from which queries prune candidates
+// with the name index instead of scoring every entry. Below it, building
+// the index costs more than the scan it saves.
+const indexMinEntries = 5000
+
+// nameIndex maps the characters and trigrams of lowercased basenames to the
+// entries containing them. Every query term needs all characters of its
+// text in the basename (and all trigrams, for substring terms), so entries
+// missing one can be skipped without changing the results.
+//
+// Entries get increasing ids, so posting lists stay sorted. Removed entries
+// are only tombstoned; the index is rebuilt once most of it is dead.
+type nameIndex struct {
+	paths    []string // by id, "" once removed
+	ids      map[string]uint32
+	chars    [256][]uint32
+	trigrams map[uint32][]uint32
+	removed  int
+}
+
+// newNameIndex indexes the given entries
+func newNameIndex(entries map[string]*DirectoryEntry) *nameIndex {
+	index := &nameIndex{
+		paths:    make([]string, 0, len(entries)),
+		ids:      make(map[string]uint32, len(entries)),
+		trigrams: make(map[uint32][]uint32),
+	}
+	for path := range entries {
+		index.add(path)
+	}
+	return index
+}
+
+// add indexes a path
+func (ix *nameIndex) add(path string) {
+	id := uint32(len(ix.paths))
+	ix.paths = append(ix.paths, path)
+	ix.ids[path] = id
+
+	name := strings.ToLower(filepath.Base(path))
+	for i := 0; i < len(name); i++ {
+		ix.chars[name[i]] = appendPosting(ix.chars[name[i]], id)
+		if i+2 < len(name) {
+			key := trigramKey(name[i : i+3])
+			ix.trigrams[key] = appendPosting(ix.trigrams[key], id)
+		}
+	}
+}
+
+// remove tombstones a path
+func (ix *nameIndex) remove(path string) {
+	id, exists := ix.ids[path]
+	if !exists {
+		return
+	}
+	delete(ix.ids, path)
+	ix.paths[id] = ""
+	ix.removed++
+}
+
+// stale reports whether enough entries were removed to warrant a rebuild
+func (ix *nameIndex) stale() bool {
+	return ix.removed > len(ix.paths)/2
+}
+
+// appendPosting adds an id to a posting list once
+func appendPosting(postings []uint32, id uint32) []uint32 {
+	if n := len(postings); n > 0 && postings[n-1] == id {
+		return postings
+	}
+	return append(postings, id)
+}
+
+// trigramKey packs three bytes into a map key
+func trigramKey(s string) uint32 {
+	return uint32(s[0])<<16 | uint32(s[1])<<8 | uint32(s[2])
+}
+
+// candidates returns the paths that may match the expression, or false if
+// the expression can't be used to prune (every path may match)
+func (ix *nameIndex) candidates(expr QueryExpr) ([]string, bool) {
+	var lists [][]uint32
+	for _, group := range expr.groups {
+		if ids, ok := ix.groupCandidates(group); ok {
+			lists = append(lists, ids)
+		}
+	}
+	if len(lists) == 0 {
+		return nil, false
+	}
+
+	var paths []string
+	for _, id := range intersectPostings(lists) {
+		if path := ix.paths[id]; path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, true
+}
+
+// groupCandidates returns the ids that may match any term of the group
+func (ix *nameIndex) groupCandidates(group queryGroup) ([]uint32, bool) {
+	var ids []uint32
+	for i, term := range group {
+		termIDs, ok := ix.termCandidates(term)
+		if !ok {
+			return nil, false
+		}
+		if i == 0 {
+			ids = termIDs
+		} else {
+			ids = unionPostings(ids, termIDs)
+		}
+	}
+	return ids, true
+}
+
+// termCandidates returns the ids whose basename contains every character of
+// a term, or every trigram for substring terms. Negated terms and terms with
+// non-ASCII characters can't prune.
+func (ix *nameIndex) termCandidates(term queryTerm) ([]uint32, bool) {
+	if term.negate || term.text == "" {
+		return nil, false
+	}
+	text := strings.ToLower(term.text)
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return nil, false
+		}
+	}
+
+	var lists [][]uint32
+	if term.kind != termFuzzy && len(text) >= 3 {
+		for i := 0; i+2 < len(text); i++ {
+			lists = append(lists, ix.trigrams[trigramKey(text[i:i+3])])
+		}
+	} else {
+		for i := 0; i < len(text); i++ {
+			lists = append(lists, ix.chars[text[i]])
+		}
+	}
+	return intersectPostings(lists), true
+}
+
+// intersectPostings returns the ids present in every list
+func intersectPostings(lists [][]uint32) []uint32 {
+	if len(lists) == 0 {
+		return nil
+	}
+	// Start from the shortest list so the result shrinks quickly
+	sort.Slice(lists, func(i, j int) bool {
+		return len(lists[i]) < len(lists[j])
+	})
+
+	result := lists[0]
+	for _, list := range lists[1:] {
+		if len(result) == 0 {
+			break
+		}
+		var kept []uint32
+		i, j := 0, 0
+		for i < len(result) && j < len(list) {
+			switch {
+			case result[i] < list[j]:
+				i++
+			case result[i] > list[j]:
+				j++
+			default:
+				kept = append(kept, result[i])
+				i++
+				j++
+			}
+		}
+		result = kept
+	}
+	return result
+}
+
+// unionPostings merges two sorted id lists
+func unionPostings(a, b []uint32) []uint32 {
+	merged := make([]uint32, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			merged = append(merged, a[i])
+			i++
+		case a[i] > b[j]:
+			merged = append(merged, b[j])
+			j++
+		default:
+			merged = append(merged, a[i])
+			i++
+			j++
+		}
+	}
+	merged = append(merged, a[i:]...)
+	return append(merged, b[j:]...)
+}
+
+// indexCandidates returns the entries that may match a query, or false if
+// every entry has to be scored: the database is small, notes are matched
+// too, or the expression can't prune (caller must hold read lock)
+func (db *Database) indexCandidates(expr QueryExpr, opts QueryOptions) ([]*DirectoryEntry, bool) {
+	if opts.MatchNotes || len(db.entries) < db.indexMinEntries {
+		return nil, false
+	}
+
+	// Readers share the database lock, so building the index is serialized
+	// separately; writers hold the database lock exclusively
+	db.indexMutex.Lock()
+	defer db.indexMutex.Unlock()
+
+	if db.index == nil || db.index.stale() {
+		db.index = newNameIndex(db.entries)
+	}
+
+	paths, ok := db.index.candidates(expr)
+	if !ok {
+		return nil, false
+	}
+	entries := make([]*DirectoryEntry, 0, len(paths))
+	for _, path := range paths {
+		if entry, exists := db.entries[path]; exists {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, true
+}
+
+// putEntry stores an entry under its path, keeping the index in sync
+// (caller must hold lock)
+func (db *Database) putEntry(entry *DirectoryEntry) {
+	if _, exists := db.entries[entry.Path]; !exists && db.index != nil {
+		db.index.add(entry.Path)
+	}
+	db.entries[entry.Path] = entry
+}
+
+// deleteEntry removes the entry for a path, keeping the index in sync
+// (caller must hold lock)
+func (db *Database) deleteEntry(path string) {
+	if db.index != nil {
+		db.index.remove(path)
+	}
+	delete(db.entries, path)
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// queryPaths returns the sorted paths of every entry matching a query
+func queryPaths(t *testing.T, db *Database, query string) []string {
+	t.Helper()
+	entries, err := db.Query(query, 0)
+	if err != nil {
+		t.Fatalf("Query(%q) failed: %v", query, err)
+	}
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// assertIndexMatchesScan checks that indexed queries return the same entries
+// as scoring every entry
+func assertIndexMatchesScan(t *testing.T, db *Database, queries []string) {
+	t.Helper()
+	for _, query := range queries {
+		db.indexMinEntries = math.MaxInt
+		linear := queryPaths(t, db, query)
+		db.indexMinEntries = 0
+		indexed := queryPaths(t, db, query)
+
+		if fmt.Sprint(linear) != fmt.Sprint(indexed) {
+			t.Errorf("Query(%q): index returned %v, scan returned %v", query, indexed, linear)
+		}
+	}
+}
+
+func TestIndexMatchesLinearScan(t *testing.T) {
+	db, err := New(DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	names := []string{
+		"project", "my-project", "Projects", "api-server", "ApiGateway",
+		"zoink", "go-zoink", "src", "docs", "node_modules", "build-2024",
+		"k8s-config", "README", "über-tool", "Kelvin-K", "p",
+	}
+	for i, name := range names {
+		db.AddVisit(fmt.Sprintf("/home/user/%d/%s", i, name))
+	}
+
+	queries := []string{
+		"proj", "PROJ", "Proj", "'proj", "^proj", "ject$", "^project$",
+		"pr", "p", "zk", "api | zoink", "api|docs", "!proj", "proj !my",
+		"ag", "k8s", "2024", "über", "ü", "k", "xyz", "'serv$", "!'node src",
+	}
+	assertIndexMatchesScan(t, db, queries)
+
+	// The index follows entries that are removed and moved
+	db.RemoveDirectory("/home/user/0/project")
+	for _, move := range [][2]string{
+		{"/home/user/5", "/home/user/renamed/5"},
+		{"/home/user/6/go-zoink", "/home/user/6/go-zoinked"},
+	} {
+		if moved, _, err := db.MovePrefix(move[0], move[1]); err != nil || moved != 1 {
+			t.Fatalf("MovePrefix(%s, %s) = %d, %v", move[0], move[1], moved, err)
+		}
+	}
+	db.AddVisit("/home/user/16/project-new")
+	assertIndexMatchesScan(t, db, append(queries, "renamed", "zoinked"))
+
+	// and is rebuilt after a reload
+	if err := db.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := db.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	assertIndexMatchesScan(t, db, queries)
+}
+
+func TestIndexSkipsTombstones(t *testing.T) {
+	entries := map[string]*DirectoryEntry{
+		"/a/project": {Path: "/a/project"},
+		"/b/other":   {Path: "/b/other"},
+	}
+	index := newNameIndex(entries)
+
+	paths, ok := index.candidates(ParseQuery("proj"))
+	if !ok || len(paths) != 1 || paths[0] != "/a/project" {
+		t.Fatalf("candidates = %v, %v; want [/a/project], true", paths, ok)
+	}
+
+	index.remove("/a/project")
+	if paths, _ := index.candidates(ParseQuery("proj")); len(paths) != 0 {
+		t.Errorf("candidates after remove = %v, want none", paths)
+	}
+	if index.stale() {
+		t.Error("index with half its entries removed should not be stale yet")
+	}
+	index.remove("/b/other")
+	if !index.stale() {
+		t.Error("index with most entries removed should be stale")
+	}
+
+	if _, ok := index.candidates(ParseQuery("!proj")); ok {
+		t.Error("negated terms should not prune")
+	}
+}
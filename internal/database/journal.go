@@ -0,0 +1,272 @@
+package database
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// maxJournalOperations is how many destructive operations can be undone
+const maxJournalOperations = 20
+
+// JournalChange is the state of one path before a destructive operation
+type JournalChange struct {
+	Path string `json:"path"`
+	// Before is the entry as it was, or nil if the operation created the path
+	Before *DirectoryEntry `json:"before,omitempty"`
+}
+
+// JournalOperation is a destructive operation recorded in the trash journal
+type JournalOperation struct {
+	Operation string          `json:"operation"`
+	Time      int64           `json:"time"`
+	Changes   []JournalChange `json:"changes"`
+	// Transitions and Choices hold the rows of the transition and choice
+	// graphs the operation rewrote or dropped, as they were before it. An
+	// empty row didn't exist.
+	Transitions map[string][]*Transition `json:"transitions,omitempty"`
+	Choices     map[string][]*Transition `json:"choices,omitempty"`
+}
+
+// StartJournal begins recording the previous state of every entry removed or
+// rewritten until the next Save, so the operation can be undone later
+func (db *Database) StartJournal(operation string) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	db.journal = &JournalOperation{Operation: operation}
+	db.journaled = make(map[string]bool)
+}
+
+// ContinueJournal is StartJournal unless an operation is already being
+// recorded, in which case its changes are added to that operation
+func (db *Database) ContinueJournal(operation string) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if db.journal == nil {
+		db.journal = &JournalOperation{Operation: operation}
+		db.journaled = make(map[string]bool)
+	}
+}
+
+// journalPath returns the location of the trash journal file
+func (db *Database) journalPath() string {
+	return db.path + ".journal"
+}
+
+// remember records the current state of path in the active journal, once per
+// operation (caller must hold lock)
+func (db *Database) remember(path string) {
+	if db.journal == nil || db.journaled[path] {
+		return
+	}
+	db.journaled[path] = true
+
+	change := JournalChange{Path: path}
+	if entry, exists := db.entries[path]; exists {
+		before := *entry
+		change.Before = &before
+	}
+	db.journal.Changes = append(db.journal.Changes, change)
+}
+
+// rememberRemoval records path before it's removed, along with the
+// transition and choice rows linking to it, which are dropped when the
+// database is saved without it (caller must hold lock)
+func (db *Database) rememberRemoval(path string) {
+	db.remember(path)
+	if db.journal == nil {
+		return
+	}
+
+	var rows []string
+	for from, targets := range db.transitions {
+		if from == path || linksTo(targets, path) {
+			rows = append(rows, from)
+		}
+	}
+	rememberRows(&db.journal.Transitions, db.transitions, rows)
+
+	rows = nil
+	for query, targets := range db.choices {
+		if linksTo(targets, path) {
+			rows = append(rows, query)
+		}
+	}
+	rememberRows(&db.journal.Choices, db.choices, rows)
+}
+
+// rememberRows records the current rows of graph with the given keys in
+// saved, once per operation (caller must hold lock)
+func rememberRows(saved *map[string][]*Transition, graph map[string][]*Transition, keys []string) {
+	if *saved == nil {
+		*saved = make(map[string][]*Transition)
+	}
+	for _, key := range keys {
+		if _, exists := (*saved)[key]; exists {
+			continue
+		}
+		row := make([]*Transition, len(graph[key]))
+		for i, transition := range graph[key] {
+			t := *transition
+			row[i] = &t
+		}
+		(*saved)[key] = row
+	}
+}
+
+// restoreRows puts saved rows back into graph (caller must hold lock)
+func restoreRows(graph map[string][]*Transition, saved map[string][]*Transition) {
+	for key, row := range saved {
+		if len(row) == 0 {
+			delete(graph, key)
+			continue
+		}
+		restored := make([]*Transition, len(row))
+		for i, transition := range row {
+			t := *transition
+			restored[i] = &t
+		}
+		graph[key] = restored
+	}
+}
+
+// flushJournal appends the active operation to the journal file once its
+// changes have been saved (caller must hold lock)
+func (db *Database) flushJournal() error {
+	if db.journal == nil {
+		return nil
+	}
+	operation := db.journal
+	db.journal = nil
+	db.journaled = nil
+
+	if len(operation.Changes) == 0 && len(operation.Transitions) == 0 && len(operation.Choices) == 0 {
+		return nil
+	}
+	operation.Time = db.clock().Unix()
+
+	operations, err := db.readJournal()
+	if err != nil {
+		return err
+	}
+	operations = append(operations, *operation)
+	if len(operations) > maxJournalOperations {
+		operations = operations[len(operations)-maxJournalOperations:]
+	}
+
+	return db.writeJournal(operations)
+}
+
+// LastOperation returns the most recent undoable operation, or nil
+func (db *Database) LastOperation() (*JournalOperation, error) {
+	operations, err := db.readJournal()
+	if err != nil || len(operations) == 0 {
+		return nil, err
+	}
+	return &operations[len(operations)-1], nil
+}
+
+// Undo reverts the most recent destructive operation, restoring every
+// affected entry with its original counts and timestamps along with the
+// transitions and choices it rewrote, and saves the database. Like Update it
+// holds the lock and reloads first. Entries and graph rows changed since the
+// operation are reset to their state from before it. Returns the operation
+// that was undone.
+func (db *Database) Undo() (*JournalOperation, error) {
+	var operation JournalOperation
+	var remaining []JournalOperation
+	err := db.update(func(tx *Tx) error {
+		operations, err := db.readJournal()
+		if err != nil {
+			return err
+		}
+		if len(operations) == 0 {
+			return fmt.Errorf("nothing to undo")
+		}
+		operation = operations[len(operations)-1]
+		remaining = operations[:len(operations)-1]
+
+		// Undoing isn't itself recorded
+		db.journal = nil
+		db.journaled = nil
+
+		// Apply in reverse so the oldest recorded state of a path wins
+		for i := len(operation.Changes) - 1; i >= 0; i-- {
+			change := operation.Changes[i]
+			if change.Before == nil {
+				db.deleteEntry(change.Path)
+				continue
+			}
+			restored := *change.Before
+			db.putEntry(&restored)
+		}
+		restoreRows(db.transitions, operation.Transitions)
+		restoreRows(db.choices, operation.Choices)
+		return nil
+	}, func() error {
+		return db.writeJournal(remaining)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &operation, nil
+}
+
+// readJournal reads all recorded operations, oldest first
+func (db *Database) readJournal() ([]JournalOperation, error) {
+	file, err := os.Open(db.journalPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	var operations []JournalOperation
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var operation JournalOperation
+		if err := json.Unmarshal(scanner.Bytes(), &operation); err != nil {
+			return nil, fmt.Errorf("failed to parse journal: %w", err)
+		}
+		operations = append(operations, operation)
+	}
+
+	return operations, scanner.Err()
+}
+
+// writeJournal replaces the journal file with the given operations, one JSON
+// document per line
+func (db *Database) writeJournal(operations []JournalOperation) error {
+	tempPath := db.journalPath() + ".tmp"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return fmt.Errorf("failed to create journal: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, operation := range operations {
+		if err := encoder.Encode(operation); err != nil {
+			return fmt.Errorf("failed to write journal: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	file.Close()
+
+	if err := os.Rename(tempPath, db.journalPath()); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to replace journal: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// snapshot returns copies of all entries keyed by path
+func snapshot(t *testing.T, db *Database) map[string]DirectoryEntry {
+	t.Helper()
+	entries, err := db.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to get entries: %v", err)
+	}
+	result := make(map[string]DirectoryEntry, len(entries))
+	for _, entry := range entries {
+		result[entry.Path] = *entry
+	}
+	return result
+}
+
+func TestUndo(t *testing.T) {
+	tempDir := t.TempDir()
+	config := DatabaseConfig{Path: filepath.Join(tempDir, "test.db")}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	db.AddVisitAt("/home/user/code", 100)
+	db.AddVisitAt("/home/user/code/api", 200)
+	db.AddVisitAt("/home/user/code/api", 300)
+	db.AddVisitAt("/home/user/work/api", 50)
+	db.AddVisitAt("/home/user/notes", 400)
+	db.AddVisit("/home/user/code/api", "/home/user/work/api")
+	db.AddVisit("/home/user/work/api", "/home/user/code/api")
+	db.RecordChoice("api", "/home/user/code/api")
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+	original := snapshot(t, db)
+
+	// Operation 1: remove
+	db.StartJournal("remove")
+	db.RemoveDirectory("/home/user/notes")
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+	afterRemove := snapshot(t, db)
+	transitions, choices := cloneGraph(db.transitions), cloneGraph(db.choices)
+
+	// Operation 2: mv with a merge
+	db.StartJournal("mv")
+	db.MovePrefix("/home/user/code", "/home/user/work")
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+
+	// Undo works from a fresh process
+	db2, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+
+	operation, err := db2.Undo()
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if operation.Operation != "mv" {
+		t.Errorf("Expected to undo mv first, got %s", operation.Operation)
+	}
+	if got := snapshot(t, db2); !reflect.DeepEqual(got, afterRemove) {
+		t.Errorf("Expected state after remove %v, got %v", afterRemove, got)
+	}
+	if !reflect.DeepEqual(db2.transitions, transitions) {
+		t.Errorf("Expected the moved transitions to be restored, got %v", db2.transitions)
+	}
+	if !reflect.DeepEqual(db2.choices, choices) {
+		t.Errorf("Expected the moved choices to be restored, got %v", db2.choices)
+	}
+
+	operation, err = db2.Undo()
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if operation.Operation != "remove" {
+		t.Errorf("Expected to undo remove second, got %s", operation.Operation)
+	}
+
+	// The restored state is persisted
+	db3, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	if got := snapshot(t, db3); !reflect.DeepEqual(got, original) {
+		t.Errorf("Expected original state %v, got %v", original, got)
+	}
+
+	if _, err := db3.Undo(); err == nil {
+		t.Error("Expected error with nothing left to undo")
+	}
+}
+
+func TestJournalIgnoresUnjournaledChanges(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := New(DatabaseConfig{Path: filepath.Join(tempDir, "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	db.AddVisit("/home/user/code")
+	db.RemoveDirectory("/home/user/code")
+	db.Save()
+
+	// An operation that changes nothing isn't recorded either
+	db.StartJournal("remove")
+	db.RemoveDirectory("/not/tracked")
+	db.Save()
+
+	last, err := db.LastOperation()
+	if err != nil {
+		t.Fatalf("LastOperation failed: %v", err)
+	}
+	if last != nil {
+		t.Errorf("Expected no undoable operation, got %+v", last)
+	}
+}
+
+func TestUndoRemoveRestoresLinks(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	config := DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	db.AddVisit("/home/user/a")
+	db.AddVisit("/home/user/b", "/home/user/a")
+	db.AddVisit("/home/user/c", "/home/user/b")
+	db.RecordChoice("b", "/home/user/b")
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+	transitions, choices := cloneGraph(db.transitions), cloneGraph(db.choices)
+
+	db.StartJournal("remove")
+	err = db.Update(func(tx *Tx) error {
+		_, err := tx.Remove("/home/user/b")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if predictions, _ := db.Next("/home/user/a", 5); len(predictions) != 0 {
+		t.Fatalf("Expected the removed directory's transitions to be dropped, got %v", predictions)
+	}
+
+	if _, err := db.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+
+	reloaded, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	predictions, _ := reloaded.Next("/home/user/a", 5)
+	if len(predictions) != 1 || predictions[0].Entry.Path != "/home/user/b" {
+		t.Errorf("Expected the move from a to b after undo, got %v", predictions)
+	}
+	predictions, _ = reloaded.Next("/home/user/b", 5)
+	if len(predictions) != 1 || predictions[0].Entry.Path != "/home/user/c" {
+		t.Errorf("Expected the move from b to c after undo, got %v", predictions)
+	}
+	if !reflect.DeepEqual(reloaded.transitions, transitions) {
+		t.Errorf("Expected transitions %v after undo, got %v", transitions, reloaded.transitions)
+	}
+	if !reflect.DeepEqual(reloaded.choices, choices) {
+		t.Errorf("Expected choices %v after undo, got %v", choices, reloaded.choices)
+	}
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+const (
+	// defaultLockTimeout is how long to wait for another process to
+	// release the database lock
+	defaultLockTimeout = 10 * time.Second
+	// lockRetryDelay is how often a held lock is retried
+	lockRetryDelay = 10 * time.Millisecond
+)
+
+// lock acquires the database file lock, exclusive for writers or shared for
+// readers. It gives up with ErrLocked after the lock timeout. The lock is an
+// flock, which the kernel releases when its holder exits, so a crashed
+// process never leaves the database locked.
+func (db *Database) lock(exclusive bool) (*flock.Flock, error) {
+	lockFile := flock.New(db.path + ".lock")
+
+	ctx, cancel := context.WithTimeout(context.Background(), db.lockTimeout)
+	defer cancel()
+
+	try := lockFile.TryRLockContext
+	if exclusive {
+		try = lockFile.TryLockContext
+	}
+	locked, err := try(ctx, lockRetryDelay)
+	if errors.Is(err, context.DeadlineExceeded) || (err == nil && !locked) {
+		return nil, fmt.Errorf("%w: gave up after %s", ErrLocked, db.lockTimeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lockFile, nil
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultVolumeRoots are directories under which removable and network
+// volumes get their own mount point directory
+var DefaultVolumeRoots = []string{"/media", "/run/media", "/mnt", "/Volumes"}
+
+// DefaultRemovedOnUnmount are the volume roots whose per-volume directories
+// are removed on unmount (macOS), rather than left behind empty
+var DefaultRemovedOnUnmount = []string{"/Volumes"}
+
+// MountTable is a snapshot of mounted and expected filesystems used to tell
+// directories that were deleted from ones on a volume that isn't mounted
+type MountTable struct {
+	// Mounted maps current mount points to their filesystem type
+	Mounted map[string]string
+	// Expected holds mount points configured in fstab, mounted or not
+	Expected map[string]bool
+	// VolumeRoots are parents of per-volume mount point directories
+	VolumeRoots []string
+	// RemovedOnUnmount are volume roots whose children vanish on unmount,
+	// so a missing child is taken to be an unmounted volume
+	RemovedOnUnmount []string
+}
+
+// LoadMountTable reads /proc/self/mountinfo and /etc/fstab. Missing files
+// (e.g. on macOS) simply leave the corresponding part of the table empty.
+func LoadMountTable() *MountTable {
+	table := &MountTable{
+		Mounted:     make(map[string]string),
+		Expected:    make(map[string]bool),
+		VolumeRoots: DefaultVolumeRoots,
+
+		RemovedOnUnmount: DefaultRemovedOnUnmount,
+	}
+
+	if file, err := os.Open("/proc/self/mountinfo"); err == nil {
+		table.parseMountInfo(file)
+		file.Close()
+	}
+	if file, err := os.Open("/etc/fstab"); err == nil {
+		table.parseFstab(file)
+		file.Close()
+	}
+
+	return table
+}
+
+// ParseMountTable builds a table from mountinfo and fstab contents; either
+// reader may be nil
+func ParseMountTable(mountInfo, fstab io.Reader, volumeRoots []string) *MountTable {
+	table := &MountTable{
+		Mounted:     make(map[string]string),
+		Expected:    make(map[string]bool),
+		VolumeRoots: volumeRoots,
+	}
+	if mountInfo != nil {
+		table.parseMountInfo(mountInfo)
+	}
+	if fstab != nil {
+		table.parseFstab(fstab)
+	}
+	return table
+}
+
+// parseMountInfo reads lines of the form
+// "36 35 98:0 /root /mnt/point rw,noatime master:1 - ext4 /dev/sda1 rw"
+func (m *MountTable) parseMountInfo(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+
+		fsType := ""
+		for i := 5; i < len(fields)-1; i++ {
+			if fields[i] == "-" {
+				fsType = fields[i+1]
+				break
+			}
+		}
+		m.Mounted[unescapeMountPath(fields[4])] = fsType
+	}
+}
+
+// parseFstab reads "device mountpoint fstype options dump pass" lines
+func (m *MountTable) parseFstab(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !filepath.IsAbs(fields[1]) || fields[2] == "swap" {
+			continue
+		}
+		m.Expected[unescapeMountPath(fields[1])] = true
+	}
+}
+
+// unescapeMountPath decodes the octal escapes (\040 for space) used in
+// mountinfo and fstab
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if code, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
+
+// isVolumeDir reports whether dir holds a volume when something is mounted
+// there or below: a per-volume or per-user directory directly below a
+// volume root (e.g. /mnt/usb, /media/alice), or a root whose children are
+// removed on unmount. Other volume roots themselves don't count, so a
+// directory deleted from /mnt on the root filesystem is really missing.
+func (m *MountTable) isVolumeDir(dir string) bool {
+	for _, root := range m.VolumeRoots {
+		if filepath.Dir(dir) == root {
+			return true
+		}
+	}
+	for _, root := range m.RemovedOnUnmount {
+		if dir == root {
+			return true
+		}
+	}
+	return false
+}
+
+// PathStatus describes whether a tracked directory can currently be used
+type PathStatus int
+
+const (
+	// PathPresent means the directory exists
+	PathPresent PathStatus = iota
+	// PathMissing means the directory was deleted
+	PathMissing
+	// PathOffline means the directory lives on a volume that isn't mounted
+	PathOffline
+)
+
+// checkPath classifies a directory using the mount table. A missing
+// directory is offline when its nearest existing ancestor is a configured
+// mount point that isn't mounted, or a per-volume directory (see
+// isVolumeDir) with nothing mounted on it.
+func (m *MountTable) checkPath(path string, stat func(string) (os.FileInfo, error)) PathStatus {
+	_, err := stat(path)
+	if err == nil || !os.IsNotExist(err) {
+		// Errors other than not-exist (permissions, I/O) never delete data
+		return PathPresent
+	}
+
+	ancestor := filepath.Dir(path)
+	for {
+		if _, err := stat(ancestor); err == nil {
+			break
+		}
+		parent := filepath.Dir(ancestor)
+		if parent == ancestor {
+			return PathMissing
+		}
+		ancestor = parent
+	}
+
+	if m.Expected[ancestor] {
+		if _, mounted := m.Mounted[ancestor]; !mounted {
+			return PathOffline
+		}
+	}
+	if m.isVolumeDir(ancestor) {
+		if _, mounted := m.Mounted[ancestor]; !mounted {
+			return PathOffline
+		}
+	}
+
+	return PathMissing
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fakeMountInfo = `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+45 22 8:17 / /mnt/backup rw,relatime shared:30 - ext4 /dev/sdb1 rw
+46 22 0:50 / /srv/my\040share rw,relatime - nfs4 server:/share rw
+`
+
+const fakeFstab = `# <file system> <mount point> <type> <options> <dump> <pass>
+UUID=1234 /          ext4 defaults 0 1
+UUID=5678 /mnt/backup ext4 defaults 0 2
+server:/data /net/data nfs defaults 0 0
+/swapfile none swap sw 0 0
+`
+
+func TestParseMountTable(t *testing.T) {
+	table := ParseMountTable(strings.NewReader(fakeMountInfo), strings.NewReader(fakeFstab), nil)
+
+	expectedMounted := map[string]string{
+		"/":             "ext4",
+		"/mnt/backup":   "ext4",
+		"/srv/my share": "nfs4",
+	}
+	if len(table.Mounted) != len(expectedMounted) {
+		t.Errorf("Expected %d mounts, got %v", len(expectedMounted), table.Mounted)
+	}
+	for path, fsType := range expectedMounted {
+		if table.Mounted[path] != fsType {
+			t.Errorf("Expected %s mounted as %s, got %q", path, fsType, table.Mounted[path])
+		}
+	}
+
+	for _, path := range []string{"/", "/mnt/backup", "/net/data"} {
+		if !table.Expected[path] {
+			t.Errorf("Expected %s in fstab mount points", path)
+		}
+	}
+	if table.Expected["none"] || len(table.Expected) != 3 {
+		t.Errorf("Unexpected fstab mount points: %v", table.Expected)
+	}
+}
+
+func TestCheckPath(t *testing.T) {
+	fsys := newFakeFS("/home/user/code", "/net/data", "/media/user", "/mnt/backup", "/mnt/usb", "/Volumes")
+
+	// /net/data is in fstab but not mounted, /mnt/backup is mounted
+	mountInfo := "45 22 8:17 / /mnt/backup rw - ext4 /dev/sdb1 rw\n"
+	fstab := "server:/data /net/data nfs defaults 0 0\n" +
+		"UUID=5678 /mnt/backup ext4 defaults 0 2\n"
+	table := ParseMountTable(strings.NewReader(mountInfo), strings.NewReader(fstab), []string{"/media", "/mnt", "/Volumes"})
+	table.RemovedOnUnmount = []string{"/Volumes"}
+
+	tests := []struct {
+		path     string
+		expected PathStatus
+	}{
+		{"/home/user/code", PathPresent},
+		{"/home/user/deleted", PathMissing},
+		{"/home/gone/deeply/nested", PathMissing},
+		{"/net/data/project", PathOffline},           // fstab mount point not mounted
+		{"/media/user/usb-disk/photos", PathOffline}, // volume directory vanished
+		{"/mnt/backup/deleted", PathMissing},         // volume mounted, really gone
+		{"/mnt/usb/photos", PathOffline},             // empty mount point, nothing mounted
+		{"/mnt/old-stuff/photos", PathMissing},       // deleted from the root filesystem
+		{"/Volumes/Backup/photos", PathOffline},      // macOS removes the volume directory
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			status := table.checkPath(tt.path, fsys.Stat)
+			if status != tt.expected {
+				t.Errorf("checkPath(%s) = %d, expected %d", tt.path, status, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCleanupKeepsOfflineEntries(t *testing.T) {
+	fsys := newFakeFS("/media/user", "/home/user")
+
+	config := DatabaseConfig{
+		Path:   filepath.Join(t.TempDir(), "test.db"),
+		Mounts: ParseMountTable(nil, nil, []string{"/media"}),
+		FS:     fsys,
+	}
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	offlinePath := "/media/user/usb-disk/photos"
+	db.AddVisit(offlinePath)
+	db.AddVisit("/home/user/deleted")
+	db.AddVisit("/home/user")
+
+	removed, err := db.CleanupMissing()
+	if err != nil {
+		t.Fatalf("CleanupMissing failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 removed entry, got %d", removed)
+	}
+
+	// Offline entries are hidden from results but kept
+	results, _ := db.Query("photos", 10)
+	if len(results) != 0 {
+		t.Errorf("Expected offline entry to be hidden, got %v", results)
+	}
+	results, _ = db.QueryWithOptions("photos", QueryOptions{IncludeOffline: true})
+	if len(results) != 1 || !results[0].IsOffline() {
+		t.Fatalf("Expected offline entry with --include-offline, got %v", results)
+	}
+
+	// The offline mark is persisted
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+	db2, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	results, _ = db2.QueryWithOptions("photos", QueryOptions{IncludeOffline: true})
+	if len(results) != 1 || !results[0].IsOffline() {
+		t.Fatalf("Expected offline mark after reload, got %v", results)
+	}
+
+	// Once the volume is back the entry shows up again, and cleanup clears the mark
+	fsys.Mkdir(offlinePath)
+	results, _ = db2.Query("photos", 10)
+	if len(results) != 1 {
+		t.Errorf("Expected remounted entry in results, got %v", results)
+	}
+	db2.CleanupMissing()
+	results, _ = db2.Query("photos", 10)
+	if results[0].IsOffline() {
+		t.Error("Expected cleanup to clear the offline mark")
+	}
+}
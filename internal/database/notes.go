@@ -0,0 +1,82 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// noteMatchWeight scales the score of query words matched through a note
+// rather than the path, so path matches still win
+const noteMatchWeight = 0.5
+
+// SetNote attaches a free-text note to an entry. An empty note removes it.
+func (db *Database) SetNote(path string, note string) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	entry, exists := db.mutableEntry(filepath.Clean(path))
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
+	entry.Note = strings.Join(strings.Fields(note), " ")
+
+	return nil
+}
+
+// noteFallbackScore scores a query against an entry whose path didn't match
+// it as a whole. Each word must match either the path or a word of the note;
+// note matches count for less. Returns 0 unless at least one word needed the
+// note.
+func noteFallbackScore(entry *DirectoryEntry, query string) int {
+	if entry.Note == "" {
+		return 0
+	}
+
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	usedNote := false
+	for _, word := range words {
+		if score := fuzzyMatch(entry.Path, word); score > 0 {
+			total += float64(score)
+			continue
+		}
+
+		score := noteWordScore(entry.Note, word)
+		if score == 0 {
+			return 0
+		}
+		total += float64(score) * noteMatchWeight
+		usedNote = true
+	}
+
+	if !usedNote {
+		return 0
+	}
+
+	return int(total / float64(len(words)))
+}
+
+// noteWordScore returns the best score of word against the words of a note
+// that contain it
+func noteWordScore(note, word string) int {
+	wordLower := strings.ToLower(word)
+
+	best := 0
+	for _, noteWord := range strings.Fields(note) {
+		noteWord = strings.Trim(noteWord, ",.;:()\"'")
+		if !strings.Contains(strings.ToLower(noteWord), wordLower) {
+			continue
+		}
+		if score := calculateFuzzyScore(noteWord, strings.ToLower(noteWord), word, wordLower); score > best {
+			best = score
+		}
+	}
+
+	return best
+}
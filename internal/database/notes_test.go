@@ -0,0 +1,89 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNotesFallbackMatching(t *testing.T) {
+	tempDir := t.TempDir()
+	config := DatabaseConfig{Path: filepath.Join(tempDir, "test.db")}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	db.AddVisit("/home/user/code/svc2")
+	db.AddVisit("/home/user/code/payments")
+	db.AddVisit("/home/user/code/svc3")
+
+	if err := db.SetNote("/home/user/untracked", "nope"); err == nil {
+		t.Error("Expected an error annotating an untracked path")
+	}
+	if err := db.SetNote("/home/user/code/svc2", "  payments gateway,   prod "); err != nil {
+		t.Fatalf("Failed to set note: %v", err)
+	}
+
+	// Notes are ignored unless asked for
+	results, _ := db.Query("gateway", 10)
+	if len(results) != 0 {
+		t.Errorf("Expected no matches without notes, got %v", results)
+	}
+
+	notes := QueryOptions{MatchNotes: true}
+	results, _ = db.QueryWithOptions("gateway", notes)
+	if len(results) != 1 || results[0].Path != "/home/user/code/svc2" {
+		t.Fatalf("Expected svc2 through its note, got %v", results)
+	}
+	if results[0].Note != "payments gateway, prod" {
+		t.Errorf("Expected normalized note, got %q", results[0].Note)
+	}
+
+	// Path matches outrank note matches
+	results, _ = db.QueryWithOptions("payments", notes)
+	if len(results) != 2 || results[0].Path != "/home/user/code/payments" {
+		t.Errorf("Expected the path match first, got %v", results)
+	}
+
+	// Words can mix path and note matches
+	results, _ = db.QueryWithOptions("svc prod", notes)
+	if len(results) != 1 || results[0].Path != "/home/user/code/svc2" {
+		t.Errorf("Expected svc2 for a mixed query, got %v", results)
+	}
+
+	// Notes survive a save/load round trip and can be cleared
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+	db2, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	results, _ = db2.QueryWithOptions("gateway", notes)
+	if len(results) != 1 {
+		t.Fatalf("Expected note after reload, got %v", results)
+	}
+	db2.SetNote("/home/user/code/svc2", "")
+	results, _ = db2.QueryWithOptions("gateway", notes)
+	if len(results) != 0 {
+		t.Errorf("Expected no matches after clearing the note, got %v", results)
+	}
+}
+
+func TestNoteFallbackScore(t *testing.T) {
+	entry := &DirectoryEntry{Path: "/home/user/svc2", Note: "payments gateway"}
+
+	if score := noteFallbackScore(entry, "svc"); score != 0 {
+		t.Errorf("Expected 0 when no word needs the note, got %d", score)
+	}
+	if score := noteFallbackScore(entry, "billing"); score != 0 {
+		t.Errorf("Expected 0 for an unmatched word, got %d", score)
+	}
+
+	pathOnly := fuzzyMatch("/home/user/gateway", "gateway")
+	viaNote := noteFallbackScore(entry, "gateway")
+	if viaNote <= 0 || viaNote >= pathOnly {
+		t.Errorf("Expected note match (%d) to score below a path match (%d)", viaNote, pathOnly)
+	}
+}
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// termKind selects how a query term is matched against a basename
+type termKind int
+
+const (
+	// termFuzzy matches the term as a subsequence ("proj")
+	termFuzzy termKind = iota
+	// termExact matches the term as a substring ("'proj")
+	termExact
+	// termPrefix matches names starting with the term ("^proj")
+	termPrefix
+	// termSuffix matches names ending with the term ("proj$")
+	termSuffix
+	// termEqual matches the whole name ("^proj$")
+	termEqual
+)
+
+// queryTerm is a single operator term of a query
+type queryTerm struct {
+	kind   termKind
+	text   string
+	negate bool // "!term": the entry must not match
+}
+
+// queryGroup is a set of alternative terms ("a | b"), any of which may match
+type queryGroup []queryTerm
+
+// QueryExpr is a parsed query: every group must match. Like plain fuzzy
+// queries, all terms are matched against the basename of a path.
+//
+// Syntax (fzf-style), terms separated by spaces:
+//
+//	proj     fuzzy subsequence
+//	'proj    exact substring
+//	^proj    prefix
+//	proj$    suffix
+//	^proj$   whole name
+//	!proj    exclude names containing proj (also !^proj, !proj$, !'proj)
+//	a | b    either term; also written a|b
+type QueryExpr struct {
+	groups []queryGroup
+}
+
+// ParseQuery parses a query string into a QueryExpr
+func ParseQuery(query string) QueryExpr {
+	var expr QueryExpr
+
+	tokens := strings.Fields(query)
+	var group queryGroup
+	continues := false // previous token was a lone "|"
+	for _, token := range tokens {
+		if token == "|" {
+			continues = len(group) > 0
+			continue
+		}
+
+		var alternatives queryGroup
+		for _, part := range strings.Split(token, "|") {
+			if part != "" {
+				alternatives = append(alternatives, parseTerm(part))
+			}
+		}
+		if len(alternatives) == 0 {
+			continue
+		}
+
+		if continues {
+			group = append(group, alternatives...)
+		} else {
+			if len(group) > 0 {
+				expr.groups = append(expr.groups, group)
+			}
+			group = alternatives
+		}
+		continues = false
+	}
+	if len(group) > 0 {
+		expr.groups = append(expr.groups, group)
+	}
+
+	return expr
+}
+
+// parseTerm parses the operators of a single term
+func parseTerm(token string) queryTerm {
+	term := queryTerm{kind: termFuzzy, text: token}
+
+	if len(term.text) > 1 && strings.HasPrefix(term.text, "!") {
+		term.negate = true
+		term.text = term.text[1:]
+		// A plain negated term excludes substrings, not subsequences
+		term.kind = termExact
+	}
+
+	switch {
+	case len(term.text) > 1 && strings.HasPrefix(term.text, "'"):
+		term.kind = termExact
+		term.text = term.text[1:]
+	case len(term.text) > 1 && strings.HasPrefix(term.text, "^"):
+		term.kind = termPrefix
+		term.text = term.text[1:]
+		if len(term.text) > 1 && strings.HasSuffix(term.text, "$") {
+			term.kind = termEqual
+			term.text = term.text[:len(term.text)-1]
+		}
+	case len(term.text) > 1 && strings.HasSuffix(term.text, "$"):
+		term.kind = termSuffix
+		term.text = term.text[:len(term.text)-1]
+	}
+
+	return term
+}
+
+// IsEmpty reports whether the expression has no terms
+func (q QueryExpr) IsEmpty() bool {
+	return len(q.groups) == 0
+}
+
+// Score matches a path against the expression. It returns 0 if the path
+// doesn't match, otherwise the average fuzzy score of the matched positive
+// terms (at least 1, so purely negative queries still match).
+func (q QueryExpr) Score(path string) int {
+	if q.IsEmpty() {
+		return 0
+	}
+
+	name := filepath.Base(path)
+	nameLower := strings.ToLower(name)
+
+	total := 0
+	positives := 0
+	for _, group := range q.groups {
+		best := -1
+		for _, term := range group {
+			if score, ok := term.match(path, name, nameLower); ok && score > best {
+				best = score
+			}
+		}
+		if best < 0 {
+			return 0
+		}
+		if best > 0 {
+			total += best
+			positives++
+		}
+	}
+
+	if positives == 0 {
+		return 1
+	}
+	if score := total / positives; score > 0 {
+		return score
+	}
+	return 1
+}
+
+// match reports whether the term accepts a path and the score of a positive
+// match (0 for negated terms). Like fuzzy terms, terms with uppercase
+// letters are case-sensitive.
+func (t queryTerm) match(path, name, nameLower string) (int, bool) {
+	nameCmp, textCmp := nameLower, strings.ToLower(t.text)
+	if hasUpper(t.text) {
+		nameCmp, textCmp = name, t.text
+	}
+
+	var matched bool
+	switch t.kind {
+	case termExact:
+		matched = strings.Contains(nameCmp, textCmp)
+	case termPrefix:
+		matched = strings.HasPrefix(nameCmp, textCmp)
+	case termSuffix:
+		matched = strings.HasSuffix(nameCmp, textCmp)
+	case termEqual:
+		matched = nameCmp == textCmp
+	default:
+		score := fuzzyMatch(path, t.text)
+		if t.negate {
+			return 0, score == 0
+		}
+		return score, score > 0
+	}
+
+	if t.negate {
+		return 0, !matched
+	}
+	if !matched {
+		return 0, false
+	}
+	return fuzzyMatch(path, t.text), true
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected []queryGroup
+		name     string
+	}{
+		{"proj", []queryGroup{{{kind: termFuzzy, text: "proj"}}}, "fuzzy"},
+		{"'proj", []queryGroup{{{kind: termExact, text: "proj"}}}, "exact"},
+		{"^proj", []queryGroup{{{kind: termPrefix, text: "proj"}}}, "prefix"},
+		{"proj$", []queryGroup{{{kind: termSuffix, text: "proj"}}}, "suffix"},
+		{"^proj$", []queryGroup{{{kind: termEqual, text: "proj"}}}, "whole name"},
+		{"!test", []queryGroup{{{kind: termExact, text: "test", negate: true}}}, "negation"},
+		{"!^test", []queryGroup{{{kind: termPrefix, text: "test", negate: true}}}, "negated prefix"},
+		{"api web", []queryGroup{
+			{{kind: termFuzzy, text: "api"}},
+			{{kind: termFuzzy, text: "web"}},
+		}, "and"},
+		{"api | ^web", []queryGroup{
+			{{kind: termFuzzy, text: "api"}, {kind: termPrefix, text: "web"}},
+		}, "alternation"},
+		{"api|web$ !old", []queryGroup{
+			{{kind: termFuzzy, text: "api"}, {kind: termSuffix, text: "web"}},
+			{{kind: termExact, text: "old", negate: true}},
+		}, "compact alternation"},
+		{"' ^ $ !", []queryGroup{
+			{{kind: termFuzzy, text: "'"}},
+			{{kind: termFuzzy, text: "^"}},
+			{{kind: termFuzzy, text: "$"}},
+			{{kind: termFuzzy, text: "!"}},
+		}, "lone operators are literal"},
+		{"| api |", []queryGroup{{{kind: termFuzzy, text: "api"}}}, "dangling bars"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr := ParseQuery(tt.query)
+			if !reflect.DeepEqual(expr.groups, tt.expected) {
+				t.Errorf("ParseQuery(%q) = %+v, expected %+v", tt.query, expr.groups, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQueryExprScore(t *testing.T) {
+	tests := []struct {
+		path     string
+		query    string
+		expected bool // whether it should match (score > 0)
+		name     string
+	}{
+		// Exact substring
+		{"/home/user/my-project", "'proj", true, "exact substring"},
+		{"/home/user/p-r-o-j", "'proj", false, "exact rejects subsequence"},
+		{"/home/user/p-r-o-j", "proj", true, "fuzzy accepts subsequence"},
+		{"/home/proj/other", "'proj", false, "exact only on basename"},
+
+		// Prefix and suffix
+		{"/home/user/project", "^proj", true, "prefix"},
+		{"/home/user/my-project", "^proj", false, "prefix not at start"},
+		{"/home/user/api-web", "web$", true, "suffix"},
+		{"/home/user/web-api", "web$", false, "suffix not at end"},
+		{"/home/user/API-Web", "web$", true, "suffix case insensitive"},
+		{"/home/user/api", "^api$", true, "whole name"},
+		{"/home/user/api2", "^api$", false, "whole name with extra"},
+
+		// Negation
+		{"/home/user/api", "!test", true, "negation without term"},
+		{"/home/user/api-test", "!test", false, "negation with term"},
+		{"/home/user/t-e-s-t", "!test", true, "negation is exact"},
+		{"/home/user/api-test", "!^test", true, "negated prefix"},
+
+		// Combinations
+		{"/home/user/api-server", "api !test", true, "and with negation"},
+		{"/home/user/api-test", "api !test", false, "and with failing negation"},
+		{"/home/user/web", "api | web", true, "alternation second"},
+		{"/home/user/cli", "api | web", false, "alternation none"},
+		{"/home/user/web-server", "^web|^api server$", true, "alternation and suffix"},
+		{"/home/user/web-client", "^web|^api server$", false, "alternation but no suffix"},
+		{"/home/user/api-server", "api server", true, "and of fuzzy terms"},
+		{"/home/user/api-client", "api server", false, "and of fuzzy terms fails"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := ParseQuery(tt.query).Score(tt.path)
+			if (score > 0) != tt.expected {
+				t.Errorf("Score(%q, %q) = %d, expected match: %v", tt.path, tt.query, score, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQueryExprScoring(t *testing.T) {
+	// A single fuzzy term scores exactly like fuzzyMatch
+	if got, want := ParseQuery("proj").Score("/home/my-project"), fuzzyMatch("/home/my-project", "proj"); got != want {
+		t.Errorf("Expected plain query score %d, got %d", want, got)
+	}
+
+	// Alternation uses the best alternative
+	best := ParseQuery("proj | xyz").Score("/home/project")
+	if best != fuzzyMatch("/home/project", "proj") {
+		t.Errorf("Expected the matching alternative's score, got %d", best)
+	}
+
+	if ParseQuery("").Score("/home/project") != 0 {
+		t.Error("Expected an empty query not to match")
+	}
+}
+
+func TestQueryOperators(t *testing.T) {
+	db, err := New(DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	for _, path := range []string{
+		"/home/user/api",
+		"/home/user/api-test",
+		"/home/user/web-api",
+		"/home/user/apiary",
+	} {
+		db.AddVisit(path)
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"^api !test", []string{"/home/user/api", "/home/user/apiary"}},
+		{"api$", []string{"/home/user/api", "/home/user/web-api"}},
+		{"^api$ | test$", []string{"/home/user/api", "/home/user/api-test"}},
+		{"!api", nil},
+	}
+
+	for _, tt := range tests {
+		results, err := db.Query(tt.query, 10)
+		if err != nil {
+			t.Fatalf("Query(%q) failed: %v", tt.query, err)
+		}
+		var paths []string
+		for _, result := range results {
+			paths = append(paths, result.Path)
+		}
+		sort.Strings(paths)
+		if !reflect.DeepEqual(paths, tt.expected) {
+			t.Errorf("Query(%q) = %v, expected %v", tt.query, paths, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"container/heap"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// minEntriesPerWorker is the smallest shard worth scoring on its own
+// goroutine; smaller databases are scored on the calling one
+const minEntriesPerWorker = 10000
+
+// ranksBefore reports whether m is ranked ahead of other by combined score
+func (m MatchResult) ranksBefore(other MatchResult) bool {
+	return ranksBefore(m.Entry, m.CombinedScore, other.Entry, other.CombinedScore)
+}
+
+// ranksBefore is the total order of every ranked result: pinned entries
+// first, then by score, then the most recently visited, then by path, so
+// the order never depends on map iteration
+func ranksBefore(a *DirectoryEntry, aScore float64, b *DirectoryEntry, bScore float64) bool {
+	if a.IsPinned() != b.IsPinned() {
+		return a.IsPinned()
+	}
+	if aScore != bScore {
+		return aScore > bScore
+	}
+	if a.LastVisited != b.LastVisited {
+		return a.LastVisited > b.LastVisited
+	}
+	return a.Path < b.Path
+}
+
+// rankEntries sorts entries by ranksBefore using the given score
+func rankEntries(entries []*DirectoryEntry, score func(*DirectoryEntry) float64) {
+	scores := make(map[*DirectoryEntry]float64, len(entries))
+	for _, entry := range entries {
+		scores[entry] = score(entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return ranksBefore(entries[i], scores[entries[i]], entries[j], scores[entries[j]])
+	})
+}
+
+// matchHeap keeps the k best matches with the lowest-ranked one on top so
+// it can be replaced
+type matchHeap []MatchResult
+
+func (h matchHeap) Len() int           { return len(h) }
+func (h matchHeap) Less(i, j int) bool { return h[j].ranksBefore(h[i]) }
+func (h matchHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *matchHeap) Push(x any)        { *h = append(*h, x.(MatchResult)) }
+func (h *matchHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// topMatches scores entries and keeps the k best matches in O(n log k)
+func topMatches(entries []*DirectoryEntry, k int, score func(*DirectoryEntry) (MatchResult, bool)) matchHeap {
+	var top matchHeap
+	for _, entry := range entries {
+		match, ok := score(entry)
+		if !ok {
+			continue
+		}
+		if len(top) < k {
+			heap.Push(&top, match)
+			continue
+		}
+		if match.ranksBefore(top[0]) {
+			top[0] = match
+			heap.Fix(&top, 0)
+		}
+	}
+	return top
+}
+
+// rankMatches scores entries across up to GOMAXPROCS workers, each keeping
+// its own k best matches, and returns the overall k best in rank order.
+// score must be safe to call concurrently.
+func rankMatches(entries []*DirectoryEntry, k int, score func(*DirectoryEntry) (MatchResult, bool)) []MatchResult {
+	if k <= 0 {
+		return nil
+	}
+
+	workers := min(runtime.GOMAXPROCS(0), len(entries)/minEntriesPerWorker)
+	var matches []MatchResult
+	if workers <= 1 {
+		matches = topMatches(entries, k, score)
+	} else {
+		shards := make([]matchHeap, workers)
+		size := (len(entries) + workers - 1) / workers
+
+		var wg sync.WaitGroup
+		for w := range shards {
+			start := min(w*size, len(entries))
+			end := min(start+size, len(entries))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				shards[w] = topMatches(entries[start:end], k, score)
+			}()
+		}
+		wg.Wait()
+
+		for _, shard := range shards {
+			matches = append(matches, shard...)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ranksBefore(matches[j])
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRankMatchesMatchesFullSort(t *testing.T) {
+	// Enough entries to be sharded across workers, with many tied scores
+	var entries []*DirectoryEntry
+	for i := 0; i < 3*minEntriesPerWorker; i++ {
+		entry := &DirectoryEntry{Path: fmt.Sprintf("/dir/%05d", i)}
+		if i%1000 == 7 {
+			entry.Flags |= FlagPinned
+		}
+		entries = append(entries, entry)
+	}
+	score := func(entry *DirectoryEntry) (MatchResult, bool) {
+		var n int
+		fmt.Sscanf(filepath.Base(entry.Path), "%d", &n)
+		if n%3 == 0 {
+			return MatchResult{}, false
+		}
+		return MatchResult{Entry: entry, CombinedScore: float64(n % 10)}, true
+	}
+
+	var expected []MatchResult
+	for _, entry := range entries {
+		if match, ok := score(entry); ok {
+			expected = append(expected, match)
+		}
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		return expected[i].ranksBefore(expected[j])
+	})
+
+	for _, k := range []int{1, 10, 500, len(entries)} {
+		matches := rankMatches(entries, k, score)
+		want := expected[:min(k, len(expected))]
+		if len(matches) != len(want) {
+			t.Fatalf("k=%d: got %d matches, want %d", k, len(matches), len(want))
+		}
+		for i := range want {
+			if matches[i].Entry != want[i].Entry {
+				t.Fatalf("k=%d: match %d is %s, want %s", k, i, matches[i].Entry.Path, want[i].Entry.Path)
+			}
+		}
+	}
+}
+
+func TestQueryBreaksTiesByPath(t *testing.T) {
+	db, err := New(DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	// Same basename and history, so every entry scores the same
+	for _, path := range []string{"/c/project", "/a/project", "/b/project"} {
+		db.AddVisitAt(path, 1700000000)
+	}
+
+	for run := 0; run < 10; run++ {
+		results, err := db.Query("proj", 2)
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if len(results) != 2 || results[0].Path != "/a/project" || results[1].Path != "/b/project" {
+			t.Fatalf("run %d: got %v, want /a/project then /b/project", run, results)
+		}
+	}
+}
+
+func TestResultsBreakTiesByLastVisitThenPath(t *testing.T) {
+	db, err := New(DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	// Equal visit counts and ages, so frecency ties; /c was visited last
+	db.AddVisitAt("/b/project", 1700000000)
+	db.AddVisitAt("/a/project", 1700000000)
+	db.AddVisitAt("/c/project", 1700000001)
+	expected := []string{"/c/project", "/a/project", "/b/project"}
+
+	results := map[string]func() ([]*DirectoryEntry, error){
+		"GetAll":        db.GetAll,
+		"empty query":   func() ([]*DirectoryEntry, error) { return db.Query("", 0) },
+		"query":         func() ([]*DirectoryEntry, error) { return db.Query("project", 0) },
+		"QueryMatching": func() ([]*DirectoryEntry, error) { return db.QueryMatching(Under("/"), QueryOptions{}) },
+	}
+	for name, get := range results {
+		entries, err := get()
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		var paths []string
+		for _, entry := range entries {
+			paths = append(paths, entry.Path)
+		}
+		if fmt.Sprint(paths) != fmt.Sprint(expected) {
+			t.Errorf("%s = %v, want %v", name, paths, expected)
+		}
+	}
+}
+
+func TestSaveWritesStableOrder(t *testing.T) {
+	tempDir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	paths := []string{"/home/user/a", "/home/user/b", "/srv/c", "/tmp/d", "/opt/e"}
+
+	// Build the same database twice, inserting in opposite orders
+	var files [][]byte
+	for run := 0; run < 2; run++ {
+		path := filepath.Join(tempDir, fmt.Sprintf("run%d.db", run))
+		db, err := New(DatabaseConfig{Path: path, Clock: clock.Now})
+		if err != nil {
+			t.Fatalf("Failed to create database: %v", err)
+		}
+		for i := range paths {
+			p := paths[i]
+			if run == 1 {
+				p = paths[len(paths)-1-i]
+			}
+			db.AddVisit(p)
+			db.RecordChoice("x", p)
+		}
+		if err := db.Save(); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read database: %v", err)
+		}
+		files = append(files, data)
+	}
+
+	if !bytes.Equal(files[0], files[1]) {
+		t.Error("databases with the same contents were written differently")
+	}
+}
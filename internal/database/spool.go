@@ -0,0 +1,144 @@
+package database
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SpooledVisit is a visit queued while the database was locked
+type SpooledVisit struct {
+	Path string `json:"path"`
+	From string `json:"from,omitempty"`
+	Time int64  `json:"time"`
+}
+
+// spoolPath returns where visits are queued for the database at dbPath
+func spoolPath(dbPath string) string {
+	return dbPath + ".spool"
+}
+
+// SpoolVisit queues a visit for the database at dbPath without taking its
+// lock. The next successful save by any process records it. Used when the
+// lock is held for longer than the lock timeout, so a hung process can't
+// make visits wait or get lost. Like AddVisit it saves the previous
+// directory right away.
+func SpoolVisit(dbPath, path, from string, visited time.Time) error {
+	if from != "" {
+		SavePreviousPath(from)
+	}
+
+	visit := SpooledVisit{Path: filepath.Clean(path), Time: visited.Unix()}
+	if from != "" {
+		visit.From = filepath.Clean(from)
+	}
+	line, err := json.Marshal(visit)
+	if err != nil {
+		return err
+	}
+
+	// A single appended write keeps lines from concurrent shells whole
+	file, err := os.OpenFile(spoolPath(dbPath), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open spool: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write spool: %w", err)
+	}
+	return nil
+}
+
+// replaySuffix ends the name of a spool moved aside for replay, which is
+// "<db>.spool.<id>.replay"
+const replaySuffix = ".replay"
+
+// newSpoolID returns a random ID for a spool moved aside for replay
+func newSpoolID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate spool ID: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// findReplay returns the spool moved aside for replay and its ID, or "" if
+// there is none
+func (db *Database) findReplay() (string, string, error) {
+	prefix := filepath.Base(spoolPath(db.path)) + "."
+	files, err := os.ReadDir(filepath.Dir(db.path))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to look for spool: %w", err)
+	}
+	for _, file := range files {
+		name := file.Name()
+		if len(name) > len(prefix)+len(replaySuffix) &&
+			strings.HasPrefix(name, prefix) && strings.HasSuffix(name, replaySuffix) {
+			id := name[len(prefix) : len(name)-len(replaySuffix)]
+			return filepath.Join(filepath.Dir(db.path), name), id, nil
+		}
+	}
+	return "", "", nil
+}
+
+// replaySpool records the queued visits and returns the file to remove once
+// they are saved, or "" if there were none. The spool is moved aside under a
+// new ID first, so visits queued meanwhile wait for the next save. The ID is
+// saved with the visits: a file left aside by a failed save is replayed
+// again, but one left by a save that completed (the process died before
+// removing it) is only removed (caller must hold the exclusive file lock and
+// the mutex).
+func (db *Database) replaySpool() (string, error) {
+	replayPath, id, err := db.findReplay()
+	if err != nil {
+		return "", err
+	}
+	if replayPath != "" && id == db.spoolReplayed {
+		return replayPath, nil
+	}
+	if replayPath == "" {
+		if id, err = newSpoolID(); err != nil {
+			return "", err
+		}
+		replayPath = spoolPath(db.path) + "." + id + replaySuffix
+		if err := os.Rename(spoolPath(db.path), replayPath); err != nil {
+			if os.IsNotExist(err) {
+				return "", nil
+			}
+			return "", fmt.Errorf("failed to move spool aside: %w", err)
+		}
+	}
+
+	file, err := os.Open(replayPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open spool: %w", err)
+	}
+	defer file.Close()
+
+	location := db.clock().Location()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var visit SpooledVisit
+		if err := json.Unmarshal(scanner.Bytes(), &visit); err != nil || visit.Path == "" {
+			// A line cut short by a crash loses one visit, not the others
+			continue
+		}
+		db.addVisit(visit.Path, time.Unix(visit.Time, 0).In(location))
+		if visit.From != "" {
+			db.recordTransition(visit.From, visit.Path, visit.Time)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read spool: %w", err)
+	}
+	db.spoolReplayed = id
+
+	return replayPath, nil
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// TestHelperHoldLock isn't a real test: TestSpoolWhileLockedByAnotherProcess
+// runs it in a child process to hold the database lock until stdin closes
+func TestHelperHoldLock(t *testing.T) {
+	path := os.Getenv("ZOINK_HOLD_LOCK")
+	if path == "" {
+		t.Skip("only run as a helper process")
+	}
+
+	lockFile := flock.New(path + ".lock")
+	if err := lockFile.Lock(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println("locked")
+	io.Copy(io.Discard, os.Stdin)
+	os.Exit(0)
+}
+
+// holdLock holds the database lock from another process until release is
+// called
+func holdLock(t *testing.T, path string) (release func()) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperHoldLock$")
+	cmd.Env = append(os.Environ(), "ZOINK_HOLD_LOCK="+path)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatalf("Failed to create stdin pipe: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("Failed to create stdout pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start helper process: %v", err)
+	}
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || line != "locked\n" {
+		stdin.Close()
+		cmd.Wait()
+		t.Fatalf("Helper process didn't take the lock: %q, %v", line, err)
+	}
+
+	return func() {
+		stdin.Close()
+		cmd.Wait()
+	}
+}
+
+func TestSpoolWhileLockedByAnotherProcess(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	path := filepath.Join(t.TempDir(), "test.db")
+	config := DatabaseConfig{Path: path, Clock: clock.Now, LockTimeout: 100 * time.Millisecond}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	db.AddVisit("/home/user/a")
+	if err := db.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	release := holdLock(t, path)
+	defer release()
+
+	// Opening and saving give up after the timeout instead of hanging
+	start := time.Now()
+	if _, err := New(config); !errors.Is(err, ErrLocked) {
+		t.Errorf("New returned %v, want ErrLocked", err)
+	}
+	if err := db.Save(); !errors.Is(err, ErrLocked) {
+		t.Errorf("Save returned %v, want ErrLocked", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Lock waits took %s", elapsed)
+	}
+
+	// Visits are queued without the lock
+	if err := SpoolVisit(path, "/home/user/b", "/home/user/a", clock.Now()); err != nil {
+		t.Fatalf("SpoolVisit failed: %v", err)
+	}
+	if err := SpoolVisit(path, "/home/user/b", "", clock.Now()); err != nil {
+		t.Fatalf("SpoolVisit failed: %v", err)
+	}
+	// The previous directory is available to 'z' before the visit is saved
+	if previous, err := GetPreviousPath(); err != nil || previous != "/home/user/a" {
+		t.Errorf("GetPreviousPath = %q, %v, want /home/user/a", previous, err)
+	}
+	release()
+
+	// The next save by any process records them and empties the spool
+	other, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := other.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(path + ".spool"); !os.IsNotExist(err) {
+		t.Errorf("Expected the spool to be removed, got %v", err)
+	}
+	if replays, _ := filepath.Glob(path + ".spool.*.replay"); len(replays) != 0 {
+		t.Errorf("Expected the replayed spool to be removed, got %v", replays)
+	}
+
+	reloaded, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	entry, exists := reloaded.Get("/home/user/b")
+	if !exists || entry.VisitCount != 2 || entry.LastVisited != clock.Now().Unix() {
+		t.Errorf("Expected 2 queued visits, got %+v", entry)
+	}
+	predictions, _ := reloaded.Next("/home/user/a", 1)
+	if len(predictions) != 1 || predictions[0].Entry.Path != "/home/user/b" {
+		t.Errorf("Expected the queued move from a to b, got %v", predictions)
+	}
+
+	// Saving again doesn't record them twice
+	reloaded.Save()
+	if entry, _ := reloaded.Get("/home/user/b"); entry.VisitCount != 2 {
+		t.Errorf("Expected the spool to be replayed once, got %d visits", entry.VisitCount)
+	}
+}
+
+func TestSpoolReplayedOnce(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	path := filepath.Join(t.TempDir(), "test.db")
+	config := DatabaseConfig{Path: path, Clock: clock.Now}
+
+	if err := SpoolVisit(path, "/home/user/a", "", clock.Now()); err != nil {
+		t.Fatalf("SpoolVisit failed: %v", err)
+	}
+	spooled, err := os.ReadFile(path + ".spool")
+	if err != nil {
+		t.Fatalf("Failed to read spool: %v", err)
+	}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	if err := db.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A process that died after saving but before removing the replayed
+	// spool leaves it behind
+	replayPath := path + ".spool." + db.spoolReplayed + replaySuffix
+	if err := os.WriteFile(replayPath, spooled, 0644); err != nil {
+		t.Fatalf("Failed to write replayed spool: %v", err)
+	}
+
+	other, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := other.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(replayPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the replayed spool to be removed, got %v", err)
+	}
+	if entry, _ := other.Get("/home/user/a"); entry.VisitCount != 1 {
+		t.Errorf("Expected the spool to be replayed once, got %d visits", entry.VisitCount)
+	}
+
+	// A spool left behind by a save that failed is replayed again
+	if err := SpoolVisit(path, "/home/user/b", "", clock.Now()); err != nil {
+		t.Fatalf("SpoolVisit failed: %v", err)
+	}
+	failed := errors.New("failed")
+	err = other.Update(func(tx *Tx) error {
+		if _, err := other.replaySpool(); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Update returned %v, want the function's error", err)
+	}
+	if replays, _ := filepath.Glob(path + ".spool.*.replay"); len(replays) != 1 {
+		t.Fatalf("Expected the spool to stay aside, got %v", replays)
+	}
+	if err := other.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if entry, exists := other.Get("/home/user/b"); !exists || entry.VisitCount != 1 {
+		t.Errorf("Expected the spool to be replayed again once, got %+v", entry)
+	}
+}
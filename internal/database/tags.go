@@ -0,0 +1,166 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// tagPrefix marks a query word as a tag filter ("#clientA")
+const tagPrefix = "#"
+
+// NormalizeTag strips the optional leading '#' from a tag and validates it
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), tagPrefix)
+	if tag == "" {
+		return "", fmt.Errorf("empty tag")
+	}
+	if strings.ContainsAny(tag, " \t\n#") {
+		return "", fmt.Errorf("invalid tag %q: tags can't contain spaces or '#'", tag)
+	}
+	return tag, nil
+}
+
+// HasTag reports whether the entry carries the tag (case-insensitive)
+func (e *DirectoryEntry) HasTag(tag string) bool {
+	return containsTag(e.Tags, tag)
+}
+
+// containsTag reports whether tags contains tag (case-insensitive)
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAllTags reports whether the entry carries every given tag
+func (e *DirectoryEntry) hasAllTags(tags []string) bool {
+	for _, tag := range tags {
+		if !e.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Tagged matches entries carrying the given tag
+func Tagged(tag string) Predicate {
+	tag = strings.TrimPrefix(tag, tagPrefix)
+	return func(entry *DirectoryEntry) bool {
+		return entry.HasTag(tag)
+	}
+}
+
+// AddTags adds tags to an entry. Tags it already has are ignored.
+func (db *Database) AddTags(path string, tags ...string) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	entry, exists := db.mutableEntry(filepath.Clean(path))
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
+	// Build a new slice so snapshots of the entry keep their tags
+	updated := append([]string(nil), entry.Tags...)
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return err
+		}
+		if !containsTag(updated, tag) {
+			updated = append(updated, tag)
+		}
+	}
+	sort.Strings(updated)
+	entry.Tags = updated
+
+	return nil
+}
+
+// RemoveTags removes tags from an entry, or all of its tags if none are given.
+// Returns the number of tags removed.
+func (db *Database) RemoveTags(path string, tags ...string) (int, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	entry, exists := db.mutableEntry(filepath.Clean(path))
+	if !exists {
+		return 0, fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
+	if len(tags) == 0 {
+		removed := len(entry.Tags)
+		entry.Tags = nil
+		return removed, nil
+	}
+
+	var remove []string
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return 0, err
+		}
+		remove = append(remove, tag)
+	}
+
+	var kept []string
+	for _, tag := range entry.Tags {
+		if !containsTag(remove, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	removed := len(entry.Tags) - len(kept)
+	entry.Tags = kept
+
+	return removed, nil
+}
+
+// TagCounts returns every tag in use with the number of entries carrying it
+func (db *Database) TagCounts() map[string]int {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	counts := make(map[string]int)
+	for _, entry := range db.entries {
+		for _, tag := range entry.Tags {
+			counts[tag]++
+		}
+	}
+
+	return counts
+}
+
+// mergeTags returns the sorted union of two tag lists
+func mergeTags(a, b []string) []string {
+	merged := append([]string(nil), a...)
+	for _, tag := range b {
+		if !containsTag(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// splitTagQuery separates "#tag" words from the rest of a query
+func splitTagQuery(query string) ([]string, string) {
+	if !strings.Contains(query, tagPrefix) {
+		return nil, query
+	}
+
+	var tags, words []string
+	for _, word := range strings.Fields(query) {
+		if len(word) > len(tagPrefix) && strings.HasPrefix(word, tagPrefix) {
+			tags = append(tags, strings.TrimPrefix(word, tagPrefix))
+		} else {
+			words = append(words, word)
+		}
+	}
+
+	return tags, strings.Join(words, " ")
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTagsQueryAndPersistence(t *testing.T) {
+	tempDir := t.TempDir()
+	config := DatabaseConfig{Path: filepath.Join(tempDir, "test.db")}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	// The untagged api has more visits and would normally win
+	for i := 0; i < 10; i++ {
+		db.AddVisit("/home/user/personal/api")
+	}
+	db.AddVisit("/home/user/work/acme/api")
+	db.AddVisit("/home/user/work/acme/web")
+
+	if err := db.AddTags("/home/user/untracked", "clientA"); err == nil {
+		t.Error("Expected an error tagging an untracked path")
+	}
+	if err := db.AddTags("/home/user/work/acme/api", "#clientA", "backend", "clientA"); err != nil {
+		t.Fatalf("Failed to tag: %v", err)
+	}
+	if err := db.AddTags("/home/user/work/acme/web", "clientA"); err != nil {
+		t.Fatalf("Failed to tag: %v", err)
+	}
+	if err := db.AddTags("/home/user/work/acme/web", "two words"); err == nil {
+		t.Error("Expected an error for a tag with a space")
+	}
+
+	results, _ := db.Query("#clientA api", 10)
+	if len(results) != 1 || results[0].Path != "/home/user/work/acme/api" {
+		t.Fatalf("Expected only the tagged api, got %v", results)
+	}
+
+	// Tags alone list all tagged entries, case-insensitively
+	results, _ = db.Query("#CLIENTA", 10)
+	if len(results) != 2 {
+		t.Errorf("Expected 2 entries tagged clientA, got %d", len(results))
+	}
+	results, _ = db.Query("#clientA #backend", 10)
+	if len(results) != 1 {
+		t.Errorf("Expected 1 entry with both tags, got %d", len(results))
+	}
+
+	expected := map[string]int{"backend": 1, "clientA": 2}
+	if counts := db.TagCounts(); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected tag counts %v, got %v", expected, counts)
+	}
+
+	// Tags survive a save/load round trip
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+	db2, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	results, _ = db2.Query("#backend", 10)
+	if len(results) != 1 || !reflect.DeepEqual(results[0].Tags, []string{"backend", "clientA"}) {
+		t.Fatalf("Expected tags after reload, got %v", results)
+	}
+
+	removed, err := db2.RemoveTags("/home/user/work/acme/api", "backend")
+	if err != nil || removed != 1 {
+		t.Errorf("Expected 1 tag removed, got %d (%v)", removed, err)
+	}
+	removed, _ = db2.RemoveTags("/home/user/work/acme/api")
+	if removed != 1 {
+		t.Errorf("Expected remaining tag removed, got %d", removed)
+	}
+	results, _ = db2.Query("#clientA", 10)
+	if len(results) != 1 || results[0].Path != "/home/user/work/acme/web" {
+		t.Errorf("Expected only web tagged clientA, got %v", results)
+	}
+}
+
+func TestSplitTagQuery(t *testing.T) {
+	testCases := []struct {
+		query string
+		tags  []string
+		rest  string
+	}{
+		{"api", nil, "api"},
+		{"#clientA api", []string{"clientA"}, "api"},
+		{"#a foo #b bar", []string{"a", "b"}, "foo bar"},
+		{"#", nil, "#"},
+		{"c#", nil, "c#"},
+	}
+
+	for _, tc := range testCases {
+		tags, rest := splitTagQuery(tc.query)
+		if !reflect.DeepEqual(tags, tc.tags) || rest != tc.rest {
+			t.Errorf("splitTagQuery(%q) = %v, %q; expected %v, %q", tc.query, tags, rest, tc.tags, tc.rest)
+		}
+	}
+}
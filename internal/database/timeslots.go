@@ -0,0 +1,147 @@
+package database
+
+import (
+	"encoding/binary"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	// hoursPerWeek is the number of hour-of-week time slots
+	hoursPerWeek = 7 * 24
+	// timeSlotBoost is how much an entry's share of visits around the
+	// current hour of the week can raise its score (1.0 = up to double)
+	timeSlotBoost = 1.0
+)
+
+// SlotCount is the number of visits recorded in one hour-of-week slot
+type SlotCount struct {
+	Slot  uint8 // hours since Sunday 00:00
+	Count uint16
+}
+
+// timeSlot returns the hour-of-week slot of a time
+func timeSlot(t time.Time) uint8 {
+	return uint8(int(t.Weekday())*24 + t.Hour())
+}
+
+// addTimeSlot returns slots with one more visit in slot. A new slice is
+// built so snapshots of the entry keep their histogram.
+func addTimeSlot(slots []SlotCount, slot uint8) []SlotCount {
+	updated := make([]SlotCount, 0, len(slots)+1)
+	found := false
+	for _, sc := range slots {
+		if sc.Slot == slot {
+			found = true
+			if sc.Count == ^uint16(0) {
+				// Saturated - halve everything to keep the proportions
+				return addTimeSlot(halveTimeSlots(slots), slot)
+			}
+			sc.Count++
+		}
+		updated = append(updated, sc)
+	}
+	if !found {
+		updated = append(updated, SlotCount{Slot: slot, Count: 1})
+		sort.Slice(updated, func(i, j int) bool { return updated[i].Slot < updated[j].Slot })
+	}
+	return updated
+}
+
+// halveTimeSlots returns slots with every count halved, dropping empty ones
+func halveTimeSlots(slots []SlotCount) []SlotCount {
+	var halved []SlotCount
+	for _, sc := range slots {
+		if sc.Count/2 > 0 {
+			halved = append(halved, SlotCount{Slot: sc.Slot, Count: sc.Count / 2})
+		}
+	}
+	return halved
+}
+
+// mergeTimeSlots returns the slot-wise sum of two histograms
+func mergeTimeSlots(a, b []SlotCount) []SlotCount {
+	counts := make(map[uint8]uint32)
+	for _, sc := range a {
+		counts[sc.Slot] += uint32(sc.Count)
+	}
+	for _, sc := range b {
+		counts[sc.Slot] += uint32(sc.Count)
+	}
+
+	merged := make([]SlotCount, 0, len(counts))
+	for slot, count := range counts {
+		if count > uint32(^uint16(0)) {
+			count = uint32(^uint16(0))
+		}
+		merged = append(merged, SlotCount{Slot: slot, Count: uint16(count)})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Slot < merged[j].Slot })
+	return merged
+}
+
+// timeSlotShare returns the share of an entry's visits that fell around the
+// hour of the week of t: the same hour counts fully, the neighbouring hours
+// half, so visits just before or after a usual time still count.
+func timeSlotShare(entry *DirectoryEntry, t time.Time) float64 {
+	if len(entry.TimeSlots) == 0 {
+		return 0
+	}
+
+	current := int(timeSlot(t))
+	total := 0.0
+	matched := 0.0
+	for _, sc := range entry.TimeSlots {
+		count := float64(sc.Count)
+		total += count
+
+		distance := (int(sc.Slot) - current + hoursPerWeek) % hoursPerWeek
+		switch distance {
+		case 0:
+			matched += count
+		case 1, hoursPerWeek - 1:
+			matched += count / 2
+		}
+	}
+
+	return matched / total
+}
+
+// writeTimeSlots writes an entry's hour-of-week histogram
+func writeTimeSlots(w io.Writer, slots []SlotCount) error {
+	if err := binary.Write(w, binary.LittleEndian, uint8(len(slots))); err != nil {
+		return err
+	}
+	for _, sc := range slots {
+		if err := binary.Write(w, binary.LittleEndian, sc.Slot); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, sc.Count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTimeSlots reads a histogram written by writeTimeSlots
+func readTimeSlots(r io.Reader) ([]SlotCount, error) {
+	var count uint8
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+
+	var slots []SlotCount
+	for i := uint8(0); i < count; i++ {
+		var sc SlotCount
+		if err := binary.Read(r, binary.LittleEndian, &sc.Slot); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &sc.Count); err != nil {
+			return nil, err
+		}
+		slots = append(slots, sc)
+	}
+
+	return slots, nil
+}
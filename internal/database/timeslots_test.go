@@ -0,0 +1,98 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimeAwareRanking(t *testing.T) {
+	tempDir := t.TempDir()
+	// Monday 2024-01-01 in UTC
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	config := DatabaseConfig{Path: filepath.Join(tempDir, "test.db"), Clock: clock.Now}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	// Work in the morning, personal projects in the evening (one visit more)
+	for i := 0; i < 5; i++ {
+		clock.now = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		db.AddVisit("/work/proj")
+		clock.now = time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+		db.AddVisit("/home/proj")
+	}
+	db.AddVisit("/home/proj")
+
+	clock.now = time.Date(2024, 1, 8, 10, 30, 0, 0, time.UTC)
+	results, _ := db.Query("proj", 10)
+	if results[0].Path != "/home/proj" {
+		t.Fatalf("Expected /home/proj first by frecency, got %s", results[0].Path)
+	}
+
+	timeAware := QueryOptions{TimeAware: true}
+	results, _ = db.QueryWithOptions("proj", timeAware)
+	if results[0].Path != "/work/proj" {
+		t.Errorf("Expected /work/proj first on a Monday morning, got %s", results[0].Path)
+	}
+
+	// A neighbouring hour still counts
+	clock.now = time.Date(2024, 1, 8, 21, 15, 0, 0, time.UTC)
+	results, _ = db.QueryWithOptions("", timeAware)
+	if results[0].Path != "/home/proj" {
+		t.Errorf("Expected /home/proj first on a Monday evening, got %s", results[0].Path)
+	}
+
+	// Histograms survive a save/load round trip
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+	db2, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	entries, _ := db2.Filter(Under("/home"))
+	expected := []SlotCount{{Slot: 24 + 20, Count: 6}}
+	if len(entries) != 1 || !reflect.DeepEqual(entries[0].TimeSlots, expected) {
+		t.Errorf("Expected histogram %v after reload, got %v", expected, entries)
+	}
+}
+
+func TestTimeSlotHistogram(t *testing.T) {
+	slots := addTimeSlot(nil, 30)
+	slots = addTimeSlot(slots, 5)
+	slots = addTimeSlot(slots, 30)
+	expected := []SlotCount{{Slot: 5, Count: 1}, {Slot: 30, Count: 2}}
+	if !reflect.DeepEqual(slots, expected) {
+		t.Fatalf("Expected %v, got %v", expected, slots)
+	}
+
+	// Updates don't modify the previous histogram
+	updated := addTimeSlot(slots, 5)
+	if slots[0].Count != 1 || updated[0].Count != 2 {
+		t.Errorf("Expected a copy, got %v and %v", slots, updated)
+	}
+
+	// Saturated counts halve the whole histogram
+	saturated := []SlotCount{{Slot: 1, Count: 1}, {Slot: 2, Count: 65535}}
+	expected = []SlotCount{{Slot: 2, Count: 32768}}
+	if got := addTimeSlot(saturated, 2); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v after saturation, got %v", expected, got)
+	}
+
+	merged := mergeTimeSlots([]SlotCount{{Slot: 1, Count: 2}}, []SlotCount{{Slot: 0, Count: 1}, {Slot: 1, Count: 3}})
+	expected = []SlotCount{{Slot: 0, Count: 1}, {Slot: 1, Count: 5}}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expected merged %v, got %v", expected, merged)
+	}
+
+	// Sunday 00:00 neighbours Saturday 23:00, the last slot of the week
+	entry := &DirectoryEntry{TimeSlots: []SlotCount{{Slot: hoursPerWeek - 1, Count: 2}, {Slot: 10, Count: 2}}}
+	sunday := time.Date(2024, 1, 7, 0, 30, 0, 0, time.UTC)
+	if share := timeSlotShare(entry, sunday); share != 0.25 {
+		t.Errorf("Expected a wrapped neighbour share of 0.25, got %.2f", share)
+	}
+}
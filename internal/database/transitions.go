@@ -0,0 +1,352 @@
+package database
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// transitionHalfLife is how long it takes a transition's weight to halve
+	transitionHalfLife = 14 * 24 * time.Hour
+	// minTransitionWeight is the decayed weight below which a transition is forgotten
+	minTransitionWeight = 0.05
+	// maxTransitionsPerSource bounds the destinations kept for one directory
+	maxTransitionsPerSource = 16
+	// maxTransitionSources bounds the directories transitions are kept for
+	maxTransitionSources = 2000
+	// transitionBoost is how much a destination's share of the transitions
+	// from the current directory can raise its score (1.0 = up to double)
+	transitionBoost = 1.0
+)
+
+// Transition is a decayed count of moves from one directory to another
+type Transition struct {
+	To       string
+	Weight   float64 // weight as of LastSeen
+	LastSeen int64   // Unix timestamp
+}
+
+// decayedWeight returns the transition weight decayed to the given time
+func (t *Transition) decayedWeight(now int64) float64 {
+	age := time.Duration(now-t.LastSeen) * time.Second
+	if age <= 0 {
+		return t.Weight
+	}
+	return t.Weight * math.Exp(-math.Ln2*float64(age)/float64(transitionHalfLife))
+}
+
+// Prediction is a likely next directory with its share of the transitions
+// from the current one
+type Prediction struct {
+	Entry *DirectoryEntry
+	Share float64 // 0-1
+}
+
+// recordTransition counts a move from one directory to another (caller must hold lock)
+func (db *Database) recordTransition(from, to string, now int64) {
+	from = filepath.Clean(from)
+	to = filepath.Clean(to)
+	if from == to {
+		return
+	}
+
+	db.transitions[from] = addTransition(db.transitions[from], to, now, maxTransitionsPerSource)
+}
+
+// addTransition counts one more move to a destination, keeping at most
+// limit destinations by dropping the weakest
+func addTransition(targets []*Transition, to string, now int64, limit int) []*Transition {
+	for _, transition := range targets {
+		if transition.To == to {
+			transition.Weight = transition.decayedWeight(now) + 1
+			transition.LastSeen = now
+			return targets
+		}
+	}
+
+	targets = append(targets, &Transition{To: to, Weight: 1, LastSeen: now})
+	if len(targets) > limit {
+		sort.Slice(targets, func(i, j int) bool {
+			return targets[i].decayedWeight(now) > targets[j].decayedWeight(now)
+		})
+		targets = targets[:limit]
+	}
+	return targets
+}
+
+// transitionShares returns each destination's share of the decayed
+// transition weight from a directory (caller must hold lock)
+func (db *Database) transitionShares(from string, now int64) map[string]float64 {
+	return weightShares(db.transitions[filepath.Clean(from)], now)
+}
+
+// weightShares returns each destination's share of the decayed weight
+func weightShares(targets []*Transition, now int64) map[string]float64 {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	total := 0.0
+	shares := make(map[string]float64, len(targets))
+	for _, transition := range targets {
+		weight := transition.decayedWeight(now)
+		shares[transition.To] += weight
+		total += weight
+	}
+	for to := range shares {
+		shares[to] /= total
+	}
+
+	return shares
+}
+
+// Next returns the directories most likely to be visited after from,
+// most likely first
+func (db *Database) Next(from string, maxResults int) ([]Prediction, error) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	var predictions []Prediction
+	for to, share := range db.transitionShares(from, db.clock().Unix()) {
+		entry, exists := db.entries[to]
+		if !exists || !db.isAvailable(entry) {
+			continue
+		}
+		predictions = append(predictions, Prediction{Entry: entry, Share: share})
+	}
+
+	sort.Slice(predictions, func(i, j int) bool {
+		a, b := predictions[i], predictions[j]
+		if a.Share != b.Share {
+			return a.Share > b.Share
+		}
+		if a.Entry.LastVisited != b.Entry.LastVisited {
+			return a.Entry.LastVisited > b.Entry.LastVisited
+		}
+		return a.Entry.Path < b.Entry.Path
+	})
+
+	if maxResults > 0 && len(predictions) > maxResults {
+		predictions = predictions[:maxResults]
+	}
+
+	return predictions, nil
+}
+
+// moveTransitions rewrites transitions at or below oldPrefix to newPrefix,
+// journaling the rows it changes (caller must hold lock)
+func (db *Database) moveTransitions(oldPrefix, newPrefix string) {
+	rewrite := movedPath(oldPrefix, newPrefix)
+	if db.journal != nil {
+		var rows []string
+		for from, targets := range db.transitions {
+			if hasPathPrefix(from, oldPrefix) || linksUnder(targets, oldPrefix) {
+				rows = append(rows, from, rewrite(from))
+			}
+		}
+		rememberRows(&db.journal.Transitions, db.transitions, rows)
+	}
+	db.transitions = rewriteGraph(db.transitions, rewrite, rewrite, db.clock().Unix())
+}
+
+// linksTo reports whether any of targets is path
+func linksTo(targets []*Transition, path string) bool {
+	for _, transition := range targets {
+		if transition.To == path {
+			return true
+		}
+	}
+	return false
+}
+
+// linksUnder reports whether any of targets is at or below prefix
+func linksUnder(targets []*Transition, prefix string) bool {
+	for _, transition := range targets {
+		if hasPathPrefix(transition.To, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// movedPath returns a function mapping paths at or below oldPrefix to newPrefix
+func movedPath(oldPrefix, newPrefix string) func(string) string {
+	return func(path string) string {
+		if hasPathPrefix(path, oldPrefix) {
+			return newPrefix + strings.TrimPrefix(path, oldPrefix)
+		}
+		return path
+	}
+}
+
+// rewriteGraph rebuilds a graph with rewritten sources and destinations.
+// Rewritten links can collide with existing ones, so duplicates are merged
+// at their current weight and links that now point to their source dropped.
+func rewriteGraph(graph map[string][]*Transition, rewriteSource, rewriteTarget func(string) string, now int64) map[string][]*Transition {
+	rewritten := make(map[string][]*Transition, len(graph))
+	for from, targets := range graph {
+		from = rewriteSource(from)
+		for _, transition := range targets {
+			to := rewriteTarget(transition.To)
+			if to == from {
+				continue
+			}
+
+			merged := false
+			for _, existing := range rewritten[from] {
+				if existing.To == to {
+					existing.Weight = existing.decayedWeight(now) + transition.decayedWeight(now)
+					existing.LastSeen = now
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				rewritten[from] = append(rewritten[from], &Transition{
+					To:       to,
+					Weight:   transition.Weight,
+					LastSeen: transition.LastSeen,
+				})
+			}
+		}
+	}
+	return rewritten
+}
+
+// pruneTransitions forgets decayed transitions and those involving
+// directories no longer tracked, and bounds the number of sources (caller
+// must hold lock)
+func (db *Database) pruneTransitions(now int64) {
+	pruneGraph(db.transitions, now, db.isTracked, db.isTracked, maxTransitionSources)
+}
+
+// isTracked reports whether a path is in the database (caller must hold lock)
+func (db *Database) isTracked(path string) bool {
+	_, exists := db.entries[path]
+	return exists
+}
+
+// pruneGraph drops decayed links, links whose source or destination is no
+// longer valid, and the weakest sources beyond maxSources
+func pruneGraph(graph map[string][]*Transition, now int64, validSource, validTarget func(string) bool, maxSources int) {
+	type source struct {
+		from   string
+		weight float64
+	}
+	var sources []source
+
+	for from, targets := range graph {
+		if !validSource(from) {
+			delete(graph, from)
+			continue
+		}
+
+		kept := targets[:0]
+		total := 0.0
+		for _, transition := range targets {
+			weight := transition.decayedWeight(now)
+			if !validTarget(transition.To) || weight < minTransitionWeight {
+				continue
+			}
+			kept = append(kept, transition)
+			total += weight
+		}
+		if len(kept) == 0 {
+			delete(graph, from)
+			continue
+		}
+		graph[from] = kept
+		sources = append(sources, source{from: from, weight: total})
+	}
+
+	if len(sources) > maxSources {
+		sort.Slice(sources, func(i, j int) bool {
+			return sources[i].weight > sources[j].weight
+		})
+		for _, s := range sources[maxSources:] {
+			delete(graph, s.from)
+		}
+	}
+}
+
+// writeTransitions writes a transition graph, sorted so the output only
+// changes with its contents
+func writeTransitions(w io.Writer, transitions map[string][]*Transition) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(transitions))); err != nil {
+		return err
+	}
+	sources := make([]string, 0, len(transitions))
+	for from := range transitions {
+		sources = append(sources, from)
+	}
+	sort.Strings(sources)
+
+	for _, from := range sources {
+		targets := append([]*Transition(nil), transitions[from]...)
+		sort.Slice(targets, func(i, j int) bool {
+			return targets[i].To < targets[j].To
+		})
+		if err := writeString(w, from); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, uint32(len(targets))); err != nil {
+			return err
+		}
+		for _, transition := range targets {
+			if err := writeString(w, transition.To); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.LittleEndian, transition.Weight); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.LittleEndian, transition.LastSeen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readTransitions reads a transition graph written by writeTransitions
+func readTransitions(r io.Reader) (map[string][]*Transition, error) {
+	var sourceCount uint32
+	if err := binary.Read(r, binary.LittleEndian, &sourceCount); err != nil {
+		return nil, err
+	}
+
+	transitions := make(map[string][]*Transition, sourceCount)
+	for i := uint32(0); i < sourceCount; i++ {
+		from, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+
+		var targetCount uint32
+		if err := binary.Read(r, binary.LittleEndian, &targetCount); err != nil {
+			return nil, err
+		}
+
+		targets := make([]*Transition, 0, targetCount)
+		for j := uint32(0); j < targetCount; j++ {
+			transition := &Transition{}
+			if transition.To, err = readString(r); err != nil {
+				return nil, err
+			}
+			if err := binary.Read(r, binary.LittleEndian, &transition.Weight); err != nil {
+				return nil, err
+			}
+			if err := binary.Read(r, binary.LittleEndian, &transition.LastSeen); err != nil {
+				return nil, err
+			}
+			targets = append(targets, transition)
+		}
+		transitions[from] = targets
+	}
+
+	return transitions, nil
+}
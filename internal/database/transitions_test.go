@@ -0,0 +1,120 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTransitionsPredictNext(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir) // AddVisit writes previous.txt
+	config := DatabaseConfig{Path: filepath.Join(tempDir, "test.db")}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	db.AddVisit("/home/user/code/api")
+	for i := 0; i < 3; i++ {
+		db.AddVisit("/home/user/code/api-docs", "/home/user/code/api")
+	}
+	db.AddVisit("/home/user/code/web", "/home/user/code/api")
+	db.AddVisit("/home/user/code/api", "/home/user/code/api") // not a move
+
+	predictions, err := db.Next("/home/user/code/api", 10)
+	if err != nil {
+		t.Fatalf("Failed to predict: %v", err)
+	}
+	if len(predictions) != 2 || predictions[0].Entry.Path != "/home/user/code/api-docs" {
+		t.Fatalf("Expected api-docs first, got %v", predictions)
+	}
+	if share := predictions[0].Share; share < 0.74 || share > 0.76 {
+		t.Errorf("Expected a 75%% share, got %.2f", share)
+	}
+
+	// Transitions survive a save/load round trip
+	if err := db.Save(); err != nil {
+		t.Fatalf("Failed to save database: %v", err)
+	}
+	db2, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	predictions, _ = db2.Next("/home/user/code/api", 1)
+	if len(predictions) != 1 || predictions[0].Entry.Path != "/home/user/code/api-docs" {
+		t.Errorf("Expected api-docs after reload, got %v", predictions)
+	}
+
+	// Transitions follow moved directories
+	db2.MovePrefix("/home/user/code", "/home/user/src")
+	predictions, _ = db2.Next("/home/user/src/api", 1)
+	if len(predictions) != 1 || predictions[0].Entry.Path != "/home/user/src/api-docs" {
+		t.Errorf("Expected moved transitions, got %v", predictions)
+	}
+}
+
+func TestTransitionBoostInQuery(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+
+	db, err := New(DatabaseConfig{Path: filepath.Join(tempDir, "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	// Equal matches, but web-client is usually reached from the backend
+	for i := 0; i < 3; i++ {
+		db.AddVisit("/home/user/api-client")
+		db.AddVisit("/home/user/web-client", "/home/user/backend")
+	}
+	db.AddVisit("/home/user/api-client")
+
+	results, _ := db.Query("client", 10)
+	if results[0].Path != "/home/user/api-client" {
+		t.Fatalf("Expected api-client first without context, got %s", results[0].Path)
+	}
+
+	results, _ = db.QueryWithOptions("client", QueryOptions{From: "/home/user/backend"})
+	if results[0].Path != "/home/user/web-client" {
+		t.Errorf("Expected web-client first from the backend, got %s", results[0].Path)
+	}
+}
+
+func TestTransitionsAreBoundedAndDecay(t *testing.T) {
+	db, err := New(DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	now := time.Now().Unix()
+	from := "/home/user/hub"
+	db.AddVisitAt(from, now)
+	for i := 0; i < maxTransitionsPerSource+5; i++ {
+		to := fmt.Sprintf("/home/user/spoke%d", i)
+		db.AddVisitAt(to, now)
+		db.recordTransition(from, to, now)
+	}
+	if len(db.transitions[from]) != maxTransitionsPerSource {
+		t.Errorf("Expected %d destinations, got %d", maxTransitionsPerSource, len(db.transitions[from]))
+	}
+
+	// A move recorded long ago decays away
+	db.AddVisitAt("/home/user/old", now)
+	db.recordTransition("/home/user/old", from, now-365*24*60*60)
+	db.pruneTransitions(now)
+	if _, exists := db.transitions["/home/user/old"]; exists {
+		t.Error("Expected the decayed transition to be forgotten")
+	}
+
+	// Transitions to untracked directories are dropped
+	db.RemoveDirectory("/home/user/spoke0")
+	db.pruneTransitions(now)
+	for _, transition := range db.transitions[from] {
+		if transition.To == "/home/user/spoke0" {
+			t.Error("Expected the transition to a removed entry to be dropped")
+		}
+	}
+}
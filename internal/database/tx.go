@@ -0,0 +1,237 @@
+package database
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"time"
+)
+
+// Tx is a transaction passed to View and Update. Entries it returns are
+// copies: they don't change with the database and changing them doesn't
+// change it. A Tx must not be used after its function returns.
+type Tx struct {
+	db       *Database
+	writable bool
+}
+
+// txSnapshot is the in-memory state an Update rolls back to
+type txSnapshot struct {
+	entries     map[string]*DirectoryEntry
+	transitions map[string][]*Transition
+	choices     map[string][]*Transition
+	evicted     uint64
+	spool       string
+	imports     map[string]ImportMarker
+}
+
+// View runs fn in a read-only transaction over a consistent view of the
+// database
+func (db *Database) View(fn func(tx *Tx) error) error {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	return fn(&Tx{db: db})
+}
+
+// Update runs fn in a read-write transaction while holding the exclusive
+// database file lock. The database is reloaded first, so fn sees changes
+// saved by other processes and unsaved changes made outside the transaction
+// are dropped. If fn returns an error every change it made is rolled back;
+// otherwise all of them are saved in one durable write. Changes are recorded
+// in the journal if StartJournal was called before.
+func (db *Database) Update(fn func(tx *Tx) error) error {
+	return db.update(fn, db.flushJournal)
+}
+
+// update is Update with the step run after a successful save, still holding
+// the lock
+func (db *Database) update(fn func(tx *Tx) error, saved func() error) error {
+	if db.readOnly {
+		return ErrReadOnly
+	}
+
+	lockFile, err := db.lock(true)
+	if err != nil {
+		return err
+	}
+	defer lockFile.Unlock()
+
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	before := db.snapshot()
+	if err := db.loadFile(); err != nil {
+		db.rollback(before)
+		return err
+	}
+
+	before = db.snapshot()
+	if err := fn(&Tx{db: db, writable: true}); err != nil {
+		db.rollback(before)
+		return err
+	}
+	if err := db.save(); err != nil {
+		db.rollback(before)
+		return err
+	}
+
+	return saved()
+}
+
+// snapshot captures the state an Update can roll back to. Entries are
+// replaced rather than changed in place, so copying the map is enough;
+// transitions are updated in place and copied deeply (caller must hold lock)
+func (db *Database) snapshot() txSnapshot {
+	return txSnapshot{
+		entries:     maps.Clone(db.entries),
+		transitions: cloneGraph(db.transitions),
+		choices:     cloneGraph(db.choices),
+		evicted:     db.evicted,
+		spool:       db.spoolReplayed,
+		imports:     db.imports,
+	}
+}
+
+// rollback restores a snapshot and drops the journal operation being
+// recorded (caller must hold lock)
+func (db *Database) rollback(s txSnapshot) {
+	db.entries = s.entries
+	db.transitions = s.transitions
+	db.choices = s.choices
+	db.evicted = s.evicted
+	db.spoolReplayed = s.spool
+	db.imports = s.imports
+	db.index = nil
+	db.journal = nil
+	db.journaled = nil
+}
+
+// cloneGraph deeply copies a transition graph
+func cloneGraph(graph map[string][]*Transition) map[string][]*Transition {
+	cloned := make(map[string][]*Transition, len(graph))
+	for from, targets := range graph {
+		copied := make([]*Transition, len(targets))
+		for i, transition := range targets {
+			t := *transition
+			copied[i] = &t
+		}
+		cloned[from] = copied
+	}
+	return cloned
+}
+
+// mutableEntry replaces the entry for a path with a copy and returns it, so
+// entries handed out earlier never change underneath their holders (caller
+// must hold lock)
+func (db *Database) mutableEntry(path string) (*DirectoryEntry, bool) {
+	entry, exists := db.entries[path]
+	if !exists {
+		return nil, false
+	}
+	updated := *entry
+	db.entries[path] = &updated
+	return &updated, true
+}
+
+// copyEntries returns copies of entries
+func copyEntries(entries []*DirectoryEntry) []*DirectoryEntry {
+	copied := make([]*DirectoryEntry, len(entries))
+	for i, entry := range entries {
+		c := *entry
+		copied[i] = &c
+	}
+	return copied
+}
+
+// checkWritable returns an error unless the transaction can change the database
+func (tx *Tx) checkWritable() error {
+	if !tx.writable {
+		return fmt.Errorf("transaction is read-only")
+	}
+	return nil
+}
+
+// Get returns a copy of the entry for a path
+func (tx *Tx) Get(path string) (*DirectoryEntry, bool) {
+	entry, exists := tx.db.entries[filepath.Clean(path)]
+	if !exists {
+		return nil, false
+	}
+	c := *entry
+	return &c, true
+}
+
+// All returns copies of every entry, pinned first, then by frecency
+func (tx *Tx) All() []*DirectoryEntry {
+	return copyEntries(tx.db.allEntries())
+}
+
+// Filter returns copies of the entries matching the predicate, sorted by path
+func (tx *Tx) Filter(predicate Predicate) []*DirectoryEntry {
+	return copyEntries(tx.db.filter(predicate))
+}
+
+// CheckPath reports whether an entry's directory still exists
+func (tx *Tx) CheckPath(path string) PathStatus {
+	return tx.db.CheckPath(path)
+}
+
+// AddVisit records a visit to a directory
+func (tx *Tx) AddVisit(path string) error {
+	if err := tx.checkWritable(); err != nil {
+		return err
+	}
+	tx.db.addVisit(path, tx.db.clock())
+	return nil
+}
+
+// AddVisitAt records a visit at a given Unix time
+func (tx *Tx) AddVisitAt(path string, timestamp int64) error {
+	if err := tx.checkWritable(); err != nil {
+		return err
+	}
+	tx.db.addVisit(path, time.Unix(timestamp, 0).In(tx.db.clock().Location()))
+	return nil
+}
+
+// Remove removes a directory and reports whether it was tracked
+func (tx *Tx) Remove(path string) (bool, error) {
+	if err := tx.checkWritable(); err != nil {
+		return false, err
+	}
+	return tx.db.removeDirectory(path), nil
+}
+
+// RemoveMatching removes every entry matching the predicate and returns how
+// many were removed
+func (tx *Tx) RemoveMatching(predicate Predicate) (int, error) {
+	if err := tx.checkWritable(); err != nil {
+		return 0, err
+	}
+	return tx.db.removeMatching(predicate), nil
+}
+
+// MovePrefix is Database.MovePrefix within the transaction
+func (tx *Tx) MovePrefix(oldPrefix, newPrefix string) (int, int, error) {
+	if err := tx.checkWritable(); err != nil {
+		return 0, 0, err
+	}
+	return tx.db.movePrefix(oldPrefix, newPrefix)
+}
+
+// SetOffline marks an entry as being on an unmounted volume or clears the mark
+func (tx *Tx) SetOffline(path string, offline bool) error {
+	if err := tx.checkWritable(); err != nil {
+		return err
+	}
+	return tx.db.setFlag(path, FlagOffline, offline)
+}
+
+// SetPinned pins or unpins an entry
+func (tx *Tx) SetPinned(path string, pinned bool) error {
+	if err := tx.checkWritable(); err != nil {
+		return err
+	}
+	return tx.db.setFlag(path, FlagPinned, pinned)
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateCommitsAndRollsBack(t *testing.T) {
+	tempDir := t.TempDir()
+	config := DatabaseConfig{Path: filepath.Join(tempDir, "test.db")}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	db.AddVisit("/home/user/keep")
+	db.AddVisit("/home/user/drop")
+	db.AddVisit("/home/user/old")
+	db.RecordChoice("old", "/home/user/old")
+	if err := db.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A failing transaction leaves no trace, in memory or in the journal
+	failure := errors.New("failure")
+	db.StartJournal("test")
+	err = db.Update(func(tx *Tx) error {
+		tx.Remove("/home/user/drop")
+		tx.MovePrefix("/home/user/old", "/home/user/new")
+		tx.SetPinned("/home/user/keep", true)
+		tx.AddVisit("/home/user/added")
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Update returned %v, want the function's error", err)
+	}
+
+	paths := queryPaths(t, db, "")
+	if len(paths) != 3 || paths[0] != "/home/user/drop" || paths[1] != "/home/user/keep" || paths[2] != "/home/user/old" {
+		t.Fatalf("Entries after rollback = %v", paths)
+	}
+	if results, _ := db.Query("old", 1); len(results) != 1 || results[0].IsPinned() {
+		t.Fatalf("Expected unchanged entries after rollback, got %v", results)
+	}
+	if db.choiceShares("old", db.clock().Unix())["/home/user/old"] == 0 {
+		t.Error("Expected choices to be rolled back")
+	}
+	if last, _ := db.LastOperation(); last != nil {
+		t.Errorf("Expected no journal entry after rollback, got %v", last)
+	}
+
+	// A successful one is saved to disk at once
+	db.StartJournal("test")
+	err = db.Update(func(tx *Tx) error {
+		if removed, err := tx.Remove("/home/user/drop"); !removed || err != nil {
+			t.Errorf("Remove = %v, %v", removed, err)
+		}
+		return tx.SetPinned("/home/user/keep", true)
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	db2, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	if paths := queryPaths(t, db2, ""); len(paths) != 2 {
+		t.Errorf("Entries on disk after commit = %v", paths)
+	}
+	if entry, _ := db2.Get("/home/user/keep"); !entry.IsPinned() {
+		t.Error("Expected pin to be saved")
+	}
+	if last, _ := db2.LastOperation(); last == nil || len(last.Changes) != 1 {
+		t.Errorf("Expected the removal in the journal, got %v", last)
+	}
+}
+
+func TestUpdateSeesOtherProcesses(t *testing.T) {
+	config := DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	other, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	other.AddVisit("/home/user/elsewhere")
+	if err := other.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	err = db.Update(func(tx *Tx) error {
+		if _, exists := tx.Get("/home/user/elsewhere"); !exists {
+			t.Error("Expected the transaction to see the other process's entry")
+		}
+		return tx.AddVisit("/home/user/here")
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	other.Reload()
+	if paths := queryPaths(t, other, ""); len(paths) != 2 {
+		t.Errorf("Entries after commit = %v, want both", paths)
+	}
+}
+
+func TestUpdateMovesOtherWritersEntries(t *testing.T) {
+	config := DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")}
+
+	watcher, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	// Another process records a visit after the watcher loaded
+	other, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	other.AddVisit("/home/user/code/api")
+	if err := other.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	err = watcher.Update(func(tx *Tx) error {
+		_, _, err := tx.MovePrefix("/home/user/code", "/home/user/work")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	reloaded, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to reload database: %v", err)
+	}
+	if paths := queryPaths(t, reloaded, "api"); len(paths) != 1 || paths[0] != "/home/user/work/api" {
+		t.Errorf("Expected the other writer's visit to be moved, got %v", paths)
+	}
+}
+
+func TestViewReturnsCopies(t *testing.T) {
+	db, err := New(DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	db.AddVisit("/home/user/project")
+
+	var entries []*DirectoryEntry
+	err = db.View(func(tx *Tx) error {
+		entries = tx.All()
+		if _, err := tx.Remove("/home/user/project"); err == nil {
+			t.Error("Expected a read-only transaction to refuse changes")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View failed: %v", err)
+	}
+
+	// Later changes don't reach the copy, and changing it doesn't reach the database
+	db.AddVisit("/home/user/project")
+	if entries[0].VisitCount != 1 {
+		t.Errorf("Copy changed to %d visits", entries[0].VisitCount)
+	}
+	entries[0].VisitCount = 100
+	if entry, _ := db.Get("/home/user/project"); entry.VisitCount != 2 {
+		t.Errorf("Database has %d visits, want 2", entry.VisitCount)
+	}
+
+	// Entries returned outside transactions are snapshots too
+	all, _ := db.GetAll()
+	db.SetPinned("/home/user/project", true)
+	if all[0].IsPinned() {
+		t.Error("Expected GetAll entries not to change after SetPinned")
+	}
+}
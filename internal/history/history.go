@@ -0,0 +1,318 @@
+package history
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// Format identifies a shell history file format
+type Format string
+
+const (
+	// FormatBash is a plain bash history file, optionally with "#<epoch>"
+	// timestamp lines written when HISTTIMEFORMAT is set
+	FormatBash Format = "bash"
+	// FormatZsh is a zsh history file, with or without EXTENDED_HISTORY
+	FormatZsh Format = "zsh"
+	// FormatFish is the YAML-like fish_history format
+	FormatFish Format = "fish"
+)
+
+// Command is a single command line from a history file
+type Command struct {
+	Line      string
+	Timestamp int64 // Unix timestamp, 0 if the format doesn't record one
+}
+
+// Visit is a directory reached by a cd-like command
+type Visit struct {
+	Path      string
+	Timestamp int64 // Unix timestamp, 0 if unknown
+	Command   int   // index of the command that entered the directory
+}
+
+// DefaultFile returns the history file of the given shell
+func DefaultFile(shellName, home string) string {
+	switch shellName {
+	case "zsh":
+		return filepath.Join(home, ".zsh_history")
+	case "fish":
+		return filepath.Join(home, ".local", "share", "fish", "fish_history")
+	default:
+		return filepath.Join(home, ".bash_history")
+	}
+}
+
+// DetectFormat guesses the format of a history file from its name and content
+func DetectFormat(path string) (Format, error) {
+	if strings.Contains(filepath.Base(path), "fish") {
+		return FormatFish, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for i := 0; i < 20 && scanner.Scan(); i++ {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "- cmd: ") {
+			return FormatFish, nil
+		}
+		if _, _, ok := parseZshExtended(line); ok {
+			return FormatZsh, nil
+		}
+	}
+
+	return FormatBash, scanner.Err()
+}
+
+// Parse reads all commands from a history file in the given format
+func Parse(r io.Reader, format Format) ([]Command, error) {
+	switch format {
+	case FormatBash:
+		return parseBash(r)
+	case FormatZsh:
+		return parseZsh(r)
+	case FormatFish:
+		return parseFish(r)
+	default:
+		return nil, fmt.Errorf("unsupported history format: %s", format)
+	}
+}
+
+// parseBash reads bash history, attaching "#<epoch>" comments to the
+// command that follows them
+func parseBash(r io.Reader) ([]Command, error) {
+	var commands []Command
+	var timestamp int64
+
+	scanner := newScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			if ts, err := strconv.ParseInt(line[1:], 10, 64); err == nil {
+				timestamp = ts
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		commands = append(commands, Command{Line: line, Timestamp: timestamp})
+		timestamp = 0
+	}
+
+	return commands, scanner.Err()
+}
+
+// parseZsh reads zsh history in both the plain and the extended
+// ": <epoch>:<duration>;<command>" form. Lines ending in a backslash are
+// joined with the next line.
+func parseZsh(r io.Reader) ([]Command, error) {
+	var commands []Command
+	var pending *Command
+
+	scanner := newScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if pending != nil {
+			pending.Line += "\n" + line
+		} else if ts, cmdLine, ok := parseZshExtended(line); ok {
+			pending = &Command{Line: cmdLine, Timestamp: ts}
+		} else {
+			pending = &Command{Line: line}
+		}
+
+		if strings.HasSuffix(line, "\\") {
+			pending.Line = strings.TrimSuffix(pending.Line, "\\")
+			continue
+		}
+		if strings.TrimSpace(pending.Line) != "" {
+			commands = append(commands, *pending)
+		}
+		pending = nil
+	}
+	if pending != nil && strings.TrimSpace(pending.Line) != "" {
+		commands = append(commands, *pending)
+	}
+
+	return commands, scanner.Err()
+}
+
+// parseZshExtended splits a ": <epoch>:<duration>;<command>" line
+func parseZshExtended(line string) (int64, string, bool) {
+	if !strings.HasPrefix(line, ": ") {
+		return 0, "", false
+	}
+	header, cmdLine, found := strings.Cut(line[2:], ";")
+	if !found {
+		return 0, "", false
+	}
+	epoch, _, found := strings.Cut(header, ":")
+	if !found {
+		return 0, "", false
+	}
+	ts, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return ts, cmdLine, true
+}
+
+// parseFish reads the fish_history format:
+//
+//   - cmd: cd ~/code
+//     when: 1700000000
+func parseFish(r io.Reader) ([]Command, error) {
+	var commands []Command
+
+	scanner := newScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if cmdLine, found := strings.CutPrefix(line, "- cmd: "); found {
+			commands = append(commands, Command{Line: unescapeFish(cmdLine)})
+			continue
+		}
+		if when, found := strings.CutPrefix(strings.TrimSpace(line), "when: "); found && len(commands) > 0 {
+			if ts, err := strconv.ParseInt(when, 10, 64); err == nil {
+				commands[len(commands)-1].Timestamp = ts
+			}
+		}
+	}
+
+	return commands, scanner.Err()
+}
+
+// unescapeFish reverses the escaping fish applies to stored commands
+func unescapeFish(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(s)
+}
+
+// newScanner returns a line scanner that tolerates very long history lines
+func newScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return scanner
+}
+
+// ExtractVisits replays cd, pushd and popd commands, simulating the working
+// directory starting at home, and returns every directory that was entered.
+// Relative targets are only resolved while the working directory is known;
+// targets that can't be resolved (variables, globs, popd) are skipped.
+func ExtractVisits(commands []Command, home string) []Visit {
+	var visits []Visit
+	cwd := home
+	previous := ""
+
+	for i, command := range commands {
+		for _, segment := range splitCommands(command.Line) {
+			words, err := shellquote.Split(segment)
+			if err != nil || len(words) == 0 {
+				continue
+			}
+
+			switch words[0] {
+			case "cd", "pushd":
+			case "popd":
+				// The directory stack isn't known, so lose track of cwd
+				previous, cwd = cwd, ""
+				continue
+			default:
+				continue
+			}
+
+			target, ok := resolveTarget(words[1:], cwd, previous, home)
+			if !ok {
+				// The shell went somewhere we can't follow
+				previous, cwd = cwd, ""
+				continue
+			}
+
+			previous, cwd = cwd, target
+			visits = append(visits, Visit{Path: target, Timestamp: command.Timestamp, Command: i})
+		}
+	}
+
+	return visits
+}
+
+// resolveTarget turns cd arguments into an absolute path
+func resolveTarget(args []string, cwd, previous, home string) (string, bool) {
+	// Skip options such as -P, -L and -e
+	for len(args) > 0 && len(args[0]) > 1 && args[0][0] == '-' {
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		return home, home != ""
+	}
+
+	arg := args[0]
+	switch {
+	case arg == "-":
+		return previous, previous != ""
+	case arg == "~" || arg == "$HOME":
+		return home, home != ""
+	case strings.HasPrefix(arg, "~/"):
+		return filepath.Join(home, arg[2:]), home != ""
+	case strings.HasPrefix(arg, "$HOME/"):
+		return filepath.Join(home, arg[6:]), home != ""
+	case strings.ContainsAny(arg, "$`*?[~"):
+		return "", false
+	case filepath.IsAbs(arg):
+		return filepath.Clean(arg), true
+	case cwd != "":
+		return filepath.Join(cwd, arg), true
+	default:
+		return "", false
+	}
+}
+
+// splitCommands splits a command line on ;, && and || outside of quotes
+func splitCommands(line string) []string {
+	var segments []string
+	var current strings.Builder
+	var quote rune
+	escaped := false
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ';' || c == '\n':
+			segments = append(segments, current.String())
+			current.Reset()
+			continue
+		case (c == '&' || c == '|') && i+1 < len(runes) && runes[i+1] == c:
+			segments = append(segments, current.String())
+			current.Reset()
+			i++
+			continue
+		}
+		current.WriteRune(c)
+	}
+	segments = append(segments, current.String())
+
+	return segments
+}
@@ -0,0 +1,132 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		input    string
+		expected []Command
+	}{
+		{
+			name:   "bash plain",
+			format: FormatBash,
+			input:  "ls\ncd /tmp\n\ncd ..\n",
+			expected: []Command{
+				{Line: "ls"}, {Line: "cd /tmp"}, {Line: "cd .."},
+			},
+		},
+		{
+			name:   "bash with timestamps",
+			format: FormatBash,
+			input:  "#1700000000\ncd /tmp\n#1700000100\ncd /var\nls\n",
+			expected: []Command{
+				{Line: "cd /tmp", Timestamp: 1700000000},
+				{Line: "cd /var", Timestamp: 1700000100},
+				{Line: "ls"},
+			},
+		},
+		{
+			name:   "zsh extended",
+			format: FormatZsh,
+			input:  ": 1700000000:0;cd /tmp\n: 1700000050:2;echo a \\\nb\ncd /var\n",
+			expected: []Command{
+				{Line: "cd /tmp", Timestamp: 1700000000},
+				{Line: "echo a \nb", Timestamp: 1700000050},
+				{Line: "cd /var"},
+			},
+		},
+		{
+			name:   "fish",
+			format: FormatFish,
+			input: "- cmd: cd ~/code\n  when: 1700000000\n  paths:\n    - ~/code\n" +
+				"- cmd: echo a\\\\nb\n  when: 1700000010\n",
+			expected: []Command{
+				{Line: "cd ~/code", Timestamp: 1700000000},
+				{Line: `echo a\nb`, Timestamp: 1700000010},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands, err := Parse(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if !reflect.DeepEqual(commands, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, commands)
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]struct {
+		content  string
+		expected Format
+	}{
+		"fish_history":  {"- cmd: ls\n", FormatFish},
+		".zsh_history":  {": 1700000000:0;ls\n", FormatZsh},
+		".bash_history": {"#1700000000\nls\n", FormatBash},
+		"custom":        {"- cmd: ls\n  when: 1\n", FormatFish},
+	}
+
+	for name, file := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(file.content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+		format, err := DetectFormat(path)
+		if err != nil {
+			t.Fatalf("DetectFormat(%s) failed: %v", name, err)
+		}
+		if format != file.expected {
+			t.Errorf("DetectFormat(%s) = %s, expected %s", name, format, file.expected)
+		}
+	}
+}
+
+func TestExtractVisits(t *testing.T) {
+	home := "/home/user"
+	commands := []Command{
+		{Line: "cd code", Timestamp: 1},
+		{Line: "cd api && make", Timestamp: 2},
+		{Line: "cd ../web; npm test", Timestamp: 3},
+		{Line: "cd -", Timestamp: 4},
+		{Line: `cd "/srv/my dir"`, Timestamp: 5},
+		{Line: "cd $PROJECT", Timestamp: 6},
+		{Line: "cd relative", Timestamp: 7}, // cwd unknown after $PROJECT
+		{Line: "cd ~/notes", Timestamp: 8},
+		{Line: "pushd -q /etc", Timestamp: 9},
+		{Line: "popd", Timestamp: 10},
+		{Line: "cd lost", Timestamp: 11}, // cwd unknown after popd
+		{Line: "cd", Timestamp: 12},
+		{Line: "echo cd /nope", Timestamp: 13},
+	}
+
+	expected := []Visit{
+		{"/home/user/code", 1, 0},
+		{"/home/user/code/api", 2, 1},
+		{"/home/user/code/web", 3, 2},
+		{"/home/user/code/api", 4, 3},
+		{"/srv/my dir", 5, 4},
+		{"/home/user/notes", 8, 7},
+		{"/etc", 9, 8},
+		{"/home/user", 12, 11},
+	}
+
+	visits := ExtractVisits(commands, home)
+	if !reflect.DeepEqual(visits, expected) {
+		t.Errorf("Expected %v, got %v", expected, visits)
+	}
+}
@@ -0,0 +1,55 @@
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// fingerprintCommands is how many commands identify where an import stopped
+const fingerprintCommands = 8
+
+// Fingerprint identifies the commands just before offset, so a later import
+// can find the position again
+func Fingerprint(commands []Command, offset int) string {
+	if offset <= 0 || offset > len(commands) {
+		return ""
+	}
+	start := max(offset-fingerprintCommands, 0)
+	hash := sha256.New()
+	for _, command := range commands[start:offset] {
+		hash.Write([]byte(command.Line))
+		hash.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Resume returns the index of the first command not imported by an import
+// that stopped at offset with the given fingerprint. If the file was
+// truncated since, the commands are searched for the fingerprint; if it's
+// gone, every remaining command is newer than the import and 0 is returned.
+func Resume(commands []Command, offset int, fingerprint string) int {
+	if offset <= 0 || fingerprint == "" {
+		return 0
+	}
+	for i := min(offset, len(commands)); i > 0; i-- {
+		if Fingerprint(commands, i) == fingerprint {
+			return i
+		}
+	}
+	return 0
+}
+
+// Pending returns the visits of the commands after an import that stopped
+// at offset with the given fingerprint, along with the offset and
+// fingerprint to save once they are imported. Visits are extracted from all
+// commands so relative targets still resolve.
+func Pending(commands []Command, home string, offset int, fingerprint string) ([]Visit, int, string) {
+	start := Resume(commands, offset, fingerprint)
+	var pending []Visit
+	for _, visit := range ExtractVisits(commands, home) {
+		if visit.Command >= start {
+			pending = append(pending, visit)
+		}
+	}
+	return pending, len(commands), Fingerprint(commands, len(commands))
+}
@@ -0,0 +1,70 @@
+package history
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// bashCommands turns plain bash history lines into commands, which have no
+// timestamps
+func bashCommands(history string) []Command {
+	commands, _ := Parse(strings.NewReader(history), FormatBash)
+	return commands
+}
+
+// visitPaths returns the paths of visits in order
+func visitPaths(visits []Visit) []string {
+	var paths []string
+	for _, visit := range visits {
+		paths = append(paths, visit.Path)
+	}
+	return paths
+}
+
+func TestPendingOnlyReturnsNewCommands(t *testing.T) {
+	home := "/home/user"
+	history := "cd code\ncd api\nls\ncd ../web\n" + strings.Repeat("make test\n", fingerprintCommands)
+
+	visits, offset, fingerprint := Pending(bashCommands(history), home, 0, "")
+	expected := []string{"/home/user/code", "/home/user/code/api", "/home/user/code/web"}
+	if paths := visitPaths(visits); !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("First import = %v, want %v", paths, expected)
+	}
+
+	// Importing the same history again finds nothing new, even though bash
+	// lines carry no timestamps to compare
+	visits, again, _ := Pending(bashCommands(history), home, offset, fingerprint)
+	if len(visits) != 0 || again != offset {
+		t.Errorf("Second import = %v at %d, want nothing at %d", visitPaths(visits), again, offset)
+	}
+
+	// Appended commands are imported, resolving relative to earlier ones
+	history += "cd ../api\ncd /srv\n"
+	visits, offset, fingerprint = Pending(bashCommands(history), home, offset, fingerprint)
+	expected = []string{"/home/user/code/api", "/srv"}
+	if paths := visitPaths(visits); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Import of appended commands = %v, want %v", paths, expected)
+	}
+
+	// Truncating the start of the file (HISTFILESIZE) doesn't reimport the rest
+	history = strings.Join(strings.Split(history, "\n")[2:], "\n") + "cd /tmp\n"
+	visits, _, _ = Pending(bashCommands(history), home, offset, fingerprint)
+	if paths := visitPaths(visits); !reflect.DeepEqual(paths, []string{"/tmp"}) {
+		t.Errorf("Import after truncation = %v, want [/tmp]", paths)
+	}
+}
+
+func TestResumeWithoutFingerprint(t *testing.T) {
+	commands := bashCommands("cd a\ncd b\n")
+	if start := Resume(commands, 1, Fingerprint(commands, 1)); start != 1 {
+		t.Errorf("Resume = %d, want 1", start)
+	}
+	// A file that no longer contains the imported commands was replaced
+	if start := Resume(commands, 2, "unknown"); start != 0 {
+		t.Errorf("Resume with an unknown fingerprint = %d, want 0", start)
+	}
+	if start := Resume(commands, 0, ""); start != 0 {
+		t.Errorf("Resume without a marker = %d, want 0", start)
+	}
+}
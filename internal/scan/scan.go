@@ -0,0 +1,251 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultMarkers are the files or directories that identify a project root
+var DefaultMarkers = []string{".git", "go.mod", "package.json", "Cargo.toml"}
+
+// Options controls how the directory tree is walked
+type Options struct {
+	// Markers identify a project root when present as a direct child
+	Markers []string
+	// Exclude holds glob patterns matched against directory basenames and
+	// full paths; matching directories are not descended into
+	Exclude []string
+	// MaxDepth limits how deep below each root the walk goes (0 = unlimited)
+	MaxDepth int
+	// Workers is the number of goroutines reading directories
+	Workers int
+}
+
+// walker holds the shared state of a concurrent scan: a fixed pool of
+// workers takes directories from a shared queue and adds their children
+type walker struct {
+	opts  Options
+	match func(dir string, children []os.DirEntry) bool
+	mutex sync.Mutex
+	ready *sync.Cond
+	queue []dirJob
+	// pending counts directories queued or being read; the walk is over
+	// when it drops to zero
+	pending int
+	found   map[string]bool
+}
+
+// dirJob is a directory waiting to be read
+type dirJob struct {
+	path  string
+	depth int
+}
+
+// FindProjects walks the given roots concurrently and returns the absolute
+// paths of all directories containing a project marker, sorted
+func FindProjects(roots []string, opts Options) ([]string, error) {
+	if len(opts.Markers) == 0 {
+		opts.Markers = DefaultMarkers
+	}
+
+	markers := make(map[string]bool, len(opts.Markers))
+	for _, marker := range opts.Markers {
+		markers[marker] = true
+	}
+
+	return find(roots, opts, func(dir string, children []os.DirEntry) bool {
+		for _, child := range children {
+			if markers[child.Name()] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// FindNamed walks the given roots concurrently and returns, for each of the
+// requested basenames, the sorted absolute paths of directories with that name
+func FindNamed(roots []string, names []string, opts Options) (map[string][]string, error) {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	paths, err := find(roots, opts, func(dir string, children []os.DirEntry) bool {
+		return wanted[filepath.Base(dir)]
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string][]string)
+	for _, path := range paths {
+		name := filepath.Base(path)
+		byName[name] = append(byName[name], path)
+	}
+
+	return byName, nil
+}
+
+// find walks the roots and returns the sorted directories accepted by match
+func find(roots []string, opts Options, match func(dir string, children []os.DirEntry) bool) ([]string, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU() * 4
+	}
+
+	w := &walker{
+		opts:  opts,
+		match: match,
+		found: make(map[string]bool),
+	}
+	w.ready = sync.NewCond(&w.mutex)
+
+	var jobs []dirJob
+	for _, root := range roots {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(absRoot)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			continue
+		}
+		jobs = append(jobs, dirJob{path: absRoot})
+	}
+	w.push(jobs)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work()
+		}()
+	}
+	wg.Wait()
+
+	paths := make([]string, 0, len(w.found))
+	for path := range w.found {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// push queues directories to be read
+func (w *walker) push(jobs []dirJob) {
+	if len(jobs) == 0 {
+		return
+	}
+
+	w.mutex.Lock()
+	w.queue = append(w.queue, jobs...)
+	w.pending += len(jobs)
+	w.mutex.Unlock()
+	w.ready.Broadcast()
+}
+
+// next waits for a queued directory; false means the walk is over. The
+// most recently queued directory goes first, which keeps the queue as small
+// as a depth-first walk would.
+func (w *walker) next() (dirJob, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for len(w.queue) == 0 && w.pending > 0 {
+		w.ready.Wait()
+	}
+	if len(w.queue) == 0 {
+		return dirJob{}, false
+	}
+	job := w.queue[len(w.queue)-1]
+	w.queue = w.queue[:len(w.queue)-1]
+	return job, true
+}
+
+// done marks a directory as read, waking idle workers once nothing is left
+func (w *walker) done() {
+	w.mutex.Lock()
+	w.pending--
+	finished := w.pending == 0
+	w.mutex.Unlock()
+	if finished {
+		w.ready.Broadcast()
+	}
+}
+
+// work reads queued directories until the walk is over
+func (w *walker) work() {
+	for {
+		job, ok := w.next()
+		if !ok {
+			return
+		}
+		w.push(w.walk(job))
+		w.done()
+	}
+}
+
+// walk reads a single directory, records it if it matches and returns its
+// subdirectories to read next
+func (w *walker) walk(job dirJob) []dirJob {
+	children, err := os.ReadDir(job.path)
+	if err != nil {
+		// Unreadable directories (permissions, races with deletion) are skipped
+		return nil
+	}
+
+	if w.match(job.path, children) {
+		w.mutex.Lock()
+		w.found[job.path] = true
+		w.mutex.Unlock()
+	}
+
+	if w.opts.MaxDepth > 0 && job.depth >= w.opts.MaxDepth {
+		return nil
+	}
+
+	var subdirs []dirJob
+	for _, child := range children {
+		// Symlinks are not followed to avoid cycles
+		if !child.IsDir() {
+			continue
+		}
+		childPath := filepath.Join(job.path, child.Name())
+		if IsExcluded(childPath, w.opts.Exclude) {
+			continue
+		}
+		subdirs = append(subdirs, dirJob{path: childPath, depth: job.depth + 1})
+	}
+	return subdirs
+}
+
+// IsExcluded reports whether a path matches any exclude pattern, either by
+// its basename or by its full path
+func IsExcluded(path string, patterns []string) bool {
+	base := filepath.Base(path)
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		if matched, _ := filepath.Match(pattern, base); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, path); matched {
+			return true
+		}
+		// Absolute patterns also exclude everything below them
+		if filepath.IsAbs(pattern) && strings.HasPrefix(path, strings.TrimSuffix(pattern, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
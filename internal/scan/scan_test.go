@@ -0,0 +1,156 @@
+package scan
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// makeTree creates directories (trailing slash) and files under root
+func makeTree(t *testing.T, root string, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		full := filepath.Join(root, p)
+		if p[len(p)-1] == '/' {
+			if err := os.MkdirAll(full, 0755); err != nil {
+				t.Fatalf("Failed to create %s: %v", full, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(full), err)
+		}
+		if err := os.WriteFile(full, nil, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", full, err)
+		}
+	}
+}
+
+func TestFindProjects(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root,
+		"code/api/go.mod",
+		"code/web/package.json",
+		"code/web/node_modules/dep/package.json",
+		"code/tool/.git/",
+		"code/notes/readme.md",
+		"deep/a/b/c/Cargo.toml",
+	)
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected []string
+	}{
+		{
+			name: "default markers with excludes",
+			opts: Options{Exclude: []string{".git", "node_modules"}},
+			expected: []string{
+				"code/api", "code/tool", "code/web", "deep/a/b/c",
+			},
+		},
+		{
+			name: "no excludes descends into dependencies",
+			opts: Options{},
+			expected: []string{
+				"code/api", "code/tool", "code/web", "code/web/node_modules/dep", "deep/a/b/c",
+			},
+		},
+		{
+			name:     "max depth",
+			opts:     Options{MaxDepth: 2, Exclude: []string{"node_modules"}},
+			expected: []string{"code/api", "code/tool", "code/web"},
+		},
+		{
+			name:     "custom markers",
+			opts:     Options{Markers: []string{"readme.md"}},
+			expected: []string{"code/notes"},
+		},
+		{
+			name:     "absolute path exclude",
+			opts:     Options{Exclude: []string{filepath.Join(root, "code")}},
+			expected: []string{"deep/a/b/c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projects, err := FindProjects([]string{root}, tt.opts)
+			if err != nil {
+				t.Fatalf("FindProjects failed: %v", err)
+			}
+
+			var expected []string
+			for _, rel := range tt.expected {
+				expected = append(expected, filepath.Join(root, rel))
+			}
+			if !reflect.DeepEqual(projects, expected) {
+				t.Errorf("Expected %v, got %v", expected, projects)
+			}
+		})
+	}
+}
+
+func TestFindUsesBoundedWorkers(t *testing.T) {
+	root := t.TempDir()
+	var paths []string
+	for i := 0; i < 300; i++ {
+		paths = append(paths, fmt.Sprintf("dir%d/sub/", i))
+	}
+	makeTree(t, root, paths...)
+
+	before := runtime.NumGoroutine()
+	var mutex sync.Mutex
+	peak := 0
+	found, err := find([]string{root}, Options{Workers: 4}, func(dir string, children []os.DirEntry) bool {
+		mutex.Lock()
+		peak = max(peak, runtime.NumGoroutine())
+		mutex.Unlock()
+		return filepath.Base(dir) == "sub"
+	})
+	if err != nil {
+		t.Fatalf("find failed: %v", err)
+	}
+
+	if len(found) != 300 {
+		t.Errorf("Expected 300 matches, got %d", len(found))
+	}
+	if peak > before+4 {
+		t.Errorf("Walk ran %d goroutines besides the %d existing ones, want at most 4 workers", peak-before, before)
+	}
+}
+
+func TestFindProjectsMissingRoot(t *testing.T) {
+	if _, err := FindProjects([]string{"/this/path/does/not/exist"}, Options{}); err == nil {
+		t.Error("Expected error for missing root")
+	}
+}
+
+func TestFindNamed(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root,
+		"work/api/",
+		"archive/2023/api/",
+		"work/web/",
+		"work/node_modules/api/",
+	)
+
+	found, err := FindNamed([]string{root}, []string{"api", "missing"}, Options{Exclude: []string{"node_modules"}})
+	if err != nil {
+		t.Fatalf("FindNamed failed: %v", err)
+	}
+
+	expected := map[string][]string{
+		"api": {
+			filepath.Join(root, "archive/2023/api"),
+			filepath.Join(root, "work/api"),
+		},
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("Expected %v, got %v", expected, found)
+	}
+}
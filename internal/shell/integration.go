@@ -13,9 +13,20 @@ func GenerateHook(shellName string) string {
 }
 
 const bashZshHook = `# Zoink shell integration
+# Exit codes: 2 no match, 3 corrupt database, 4 database from a newer zoink,
+# 5 database locked
 zoink_track() {
     if command -v zoink >/dev/null 2>&1; then
         zoink add "$PWD" "$OLDPWD" >/dev/null 2>&1
+        case $? in
+            3|4)
+                # Visits can't be recorded until the database is fixed: say so once
+                if [ -z "$ZOINK_WARNED" ]; then
+                    ZOINK_WARNED=1
+                    echo "zoink: can't record visits, the database is unreadable (see 'zoink stats')" >&2
+                fi
+                ;;
+        esac
     fi
 }
 
@@ -53,20 +64,28 @@ z() {
                 local search_args=$(echo "$@" | sed 's/-i//g; s/--interactive//g' | xargs)
                 local dir
                 if [ -n "$search_args" ]; then
-                    dir=$(zoink find --list --echo "$search_args" | fzf --height 40% --reverse --header "Select directory:")
+                    dir=$(zoink find --list --echo --with-notes "$search_args" | fzf --height 40% --reverse --header "Select directory:" | cut -f1)
                 else
-                    dir=$(zoink find --list --echo | fzf --height 40% --reverse --header "Select directory:")
+                    dir=$(zoink find --list --echo --with-notes | fzf --height 40% --reverse --header "Select directory:" | cut -f1)
+                fi
+                if [ -n "$dir" ] && [ -d "$dir" ]; then
+                    cd "$dir"
+                    [ -n "$search_args" ] && zoink record-choice "$search_args" "$dir" >/dev/null 2>&1
                 fi
-                [ -n "$dir" ] && [ -d "$dir" ] && cd "$dir"
                 ;;
             *)
                 # Non-interactive mode
-                local result
+                local result rc
                 result=$(zoink find "$@")
-                if [ $? -eq 0 ] && [ -n "$result" ] && [ -d "$result" ]; then
+                rc=$?
+                if [ $rc -ne 0 ]; then
+                    # zoink explained the failure on stderr
+                    return $rc
+                fi
+                if [ -n "$result" ] && [ -d "$result" ]; then
                     cd "$result"
-                else
-                    # If no valid directory returned, just show the output
+                elif [ -n "$result" ]; then
+                    # Not a directory (e.g. --list): just show the output
                     echo "$result"
                 fi
                 ;;
@@ -78,9 +97,19 @@ z() {
 zoink_track`
 
 const fishHook = `# Zoink shell integration
+# Exit codes: 2 no match, 3 corrupt database, 4 database from a newer zoink,
+# 5 database locked
 function zoink_track
     if command -v zoink >/dev/null 2>&1
         zoink add $PWD $OLDPWD >/dev/null 2>&1
+        switch $status
+            case 3 4
+                # Visits can't be recorded until the database is fixed: say so once
+                if not set -q zoink_warned
+                    set -g zoink_warned 1
+                    echo "zoink: can't record visits, the database is unreadable (see 'zoink stats')" >&2
+                end
+        end
     end
 end
 
@@ -132,19 +161,29 @@ function z
             end
             set dir
             if test (count $search_args) -gt 0
-                set dir (zoink find --list --echo $search_args | fzf --height 40% --reverse --header "Select directory:")
+                set dir (zoink find --list --echo --with-notes $search_args | fzf --height 40% --reverse --header "Select directory:" | cut -f1)
             else
-                set dir (zoink find --list --echo | fzf --height 40% --reverse --header "Select directory:")
+                set dir (zoink find --list --echo --with-notes | fzf --height 40% --reverse --header "Select directory:" | cut -f1)
+            end
+            if test -n "$dir" -a -d "$dir"
+                cd "$dir"
+                if test (count $search_args) -gt 0
+                    zoink record-choice "$search_args" "$dir" >/dev/null 2>&1
+                end
             end
-            test -n "$dir" -a -d "$dir"; and cd "$dir"
         else
             # Non-interactive mode
             set result (zoink find $argv)
-            if test $status -eq 0 -a -n "$result" -a -d "$result"
+            set find_status $status
+            if test $find_status -ne 0
+                # zoink explained the failure on stderr
+                return $find_status
+            end
+            if test -n "$result" -a -d "$result"
                 cd "$result"
-            else
-                # If no valid directory returned, just show the output
-                echo "$result"
+            else if test -n "$result"
+                # Not a directory (e.g. --list): just show the output
+                printf '%s\n' $result
             end
         end
     end
@@ -0,0 +1,75 @@
+package watch
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupported is returned on platforms without inotify
+var ErrUnsupported = errors.New("watch mode is only supported on Linux")
+
+// Rename is a directory moved from one path to another
+type Rename struct {
+	From string
+	To   string
+}
+
+// maxPendingMoves bounds how many unpaired move-from events are remembered.
+// Directories moved out of the watched set never get a matching move-to.
+const maxPendingMoves = 256
+
+// pairer matches move-from and move-to events by their cookie
+type pairer struct {
+	pending map[uint32]string
+	order   []uint32
+}
+
+// newPairer creates an empty pairer
+func newPairer() *pairer {
+	return &pairer{pending: make(map[uint32]string)}
+}
+
+// from records the source half of a move
+func (p *pairer) from(cookie uint32, path string) {
+	if _, exists := p.pending[cookie]; !exists {
+		p.order = append(p.order, cookie)
+	}
+	p.pending[cookie] = path
+
+	// Forget the oldest unpaired moves
+	for len(p.order) > maxPendingMoves {
+		delete(p.pending, p.order[0])
+		p.order = p.order[1:]
+	}
+}
+
+// to completes a move, returning the rename if its source half was seen
+func (p *pairer) to(cookie uint32, path string) (Rename, bool) {
+	from, exists := p.pending[cookie]
+	if !exists {
+		return Rename{}, false
+	}
+
+	delete(p.pending, cookie)
+	for i, c := range p.order {
+		if c == cookie {
+			p.order = append(p.order[:i], p.order[i+1:]...)
+			break
+		}
+	}
+
+	return Rename{From: from, To: path}, true
+}
+
+// rewritePath maps path into the new location if it lies at or below the
+// renamed directory
+func rewritePath(path string, rename Rename) (string, bool) {
+	if path == rename.From {
+		return rename.To, true
+	}
+	if strings.HasPrefix(path, rename.From+string(filepath.Separator)) {
+		return rename.To + strings.TrimPrefix(path, rename.From), true
+	}
+	return path, false
+}
@@ -0,0 +1,154 @@
+//go:build linux
+
+package watch
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Watcher reports directory renames inside a set of watched directories
+type Watcher struct {
+	Renames chan Rename
+	Errors  chan error
+
+	fd     int
+	mutex  sync.Mutex
+	paths  map[int]string // watch descriptor -> directory
+	wds    map[string]int // directory -> watch descriptor
+	pairer *pairer
+}
+
+// New creates a watcher and starts reading inotify events
+func New() (*Watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+
+	w := &Watcher{
+		Renames: make(chan Rename, 16),
+		Errors:  make(chan error, 1),
+		fd:      fd,
+		paths:   make(map[int]string),
+		wds:     make(map[string]int),
+		pairer:  newPairer(),
+	}
+	go w.readEvents()
+
+	return w, nil
+}
+
+// Watch replaces the set of watched directories, adding new directories and
+// dropping ones no longer listed. Directories that can't be watched (e.g.
+// because they no longer exist) are skipped; the number watched is returned.
+func (w *Watcher) Watch(dirs []string) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	wanted := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		wanted[filepath.Clean(dir)] = true
+	}
+
+	for path, wd := range w.wds {
+		if !wanted[path] {
+			unix.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.wds, path)
+			delete(w.paths, wd)
+		}
+	}
+
+	for dir := range wanted {
+		if _, exists := w.wds[dir]; exists {
+			continue
+		}
+		wd, err := unix.InotifyAddWatch(w.fd, dir, unix.IN_MOVED_FROM|unix.IN_MOVED_TO|unix.IN_ONLYDIR)
+		if err != nil {
+			if err == unix.ENOSPC {
+				return len(w.wds), fmt.Errorf("inotify watch limit reached (see fs.inotify.max_user_watches)")
+			}
+			continue
+		}
+		w.wds[dir] = wd
+		w.paths[wd] = dir
+	}
+
+	return len(w.wds), nil
+}
+
+// Close stops watching and releases the inotify descriptor
+func (w *Watcher) Close() error {
+	return unix.Close(w.fd)
+}
+
+// readEvents decodes inotify events until the descriptor is closed
+func (w *Watcher) readEvents() {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := unix.Read(w.fd, buf)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil || n <= 0 {
+			if err != nil && err != unix.EBADF {
+				w.Errors <- fmt.Errorf("failed to read inotify events: %w", err)
+			}
+			close(w.Renames)
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(event.Len)]
+			name := string(bytes.TrimRight(nameBytes, "\x00"))
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+
+			if event.Mask&unix.IN_ISDIR == 0 || name == "" {
+				continue
+			}
+			w.handleEvent(int(event.Wd), event.Mask, event.Cookie, name)
+		}
+	}
+}
+
+// handleEvent pairs move events and keeps the watched paths up to date
+func (w *Watcher) handleEvent(wd int, mask uint32, cookie uint32, name string) {
+	w.mutex.Lock()
+	dir, exists := w.paths[wd]
+	if !exists {
+		w.mutex.Unlock()
+		return
+	}
+	path := filepath.Join(dir, name)
+
+	if mask&unix.IN_MOVED_FROM != 0 {
+		w.pairer.from(cookie, path)
+		w.mutex.Unlock()
+		return
+	}
+
+	rename, ok := w.pairer.to(cookie, path)
+	if !ok {
+		w.mutex.Unlock()
+		return
+	}
+
+	// Watches follow the inode, so watched directories below the renamed
+	// one now live at a different path
+	for wd, watched := range w.paths {
+		if newPath, moved := rewritePath(watched, rename); moved {
+			delete(w.wds, watched)
+			w.paths[wd] = newPath
+			w.wds[newPath] = wd
+		}
+	}
+	w.mutex.Unlock()
+
+	w.Renames <- rename
+}
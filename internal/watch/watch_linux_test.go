@@ -0,0 +1,58 @@
+//go:build linux
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherDetectsRename(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"code/api", "work"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	w, err := New()
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	defer w.Close()
+
+	watched, err := w.Watch([]string{filepath.Join(root, "code"), filepath.Join(root, "work"), "/does/not/exist"})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if watched != 2 {
+		t.Errorf("Expected 2 watched directories, got %d", watched)
+	}
+
+	// A plain file rename must not be reported
+	if err := os.WriteFile(filepath.Join(root, "code", "file"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Rename(filepath.Join(root, "code", "file"), filepath.Join(root, "work", "file")); err != nil {
+		t.Fatalf("Failed to rename file: %v", err)
+	}
+
+	from := filepath.Join(root, "code", "api")
+	to := filepath.Join(root, "work", "api-server")
+	if err := os.Rename(from, to); err != nil {
+		t.Fatalf("Failed to rename directory: %v", err)
+	}
+
+	select {
+	case rename := <-w.Renames:
+		if rename.From != from || rename.To != to {
+			t.Errorf("Expected %s -> %s, got %s -> %s", from, to, rename.From, rename.To)
+		}
+	case err := <-w.Errors:
+		t.Fatalf("Watcher error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for rename event")
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+package watch
+
+// Watcher reports directory renames inside a set of watched directories
+type Watcher struct {
+	Renames chan Rename
+	Errors  chan error
+}
+
+// New always fails on platforms without inotify
+func New() (*Watcher, error) {
+	return nil, ErrUnsupported
+}
+
+// Watch is not supported on this platform
+func (w *Watcher) Watch(dirs []string) (int, error) {
+	return 0, ErrUnsupported
+}
+
+// Close is a no-op on this platform
+func (w *Watcher) Close() error {
+	return nil
+}
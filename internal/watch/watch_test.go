@@ -0,0 +1,59 @@
+package watch
+
+import (
+	"testing"
+)
+
+func TestPairer(t *testing.T) {
+	p := newPairer()
+
+	if _, ok := p.to(1, "/a/new"); ok {
+		t.Error("Expected unpaired move-to to be ignored")
+	}
+
+	p.from(1, "/a/old")
+	p.from(2, "/a/gone") // moved out of the watched set
+	rename, ok := p.to(1, "/b/new")
+	if !ok || rename != (Rename{From: "/a/old", To: "/b/new"}) {
+		t.Errorf("Expected /a/old -> /b/new, got %v (%v)", rename, ok)
+	}
+	if _, ok := p.to(1, "/b/new"); ok {
+		t.Error("Expected cookie to be consumed")
+	}
+
+	// Old unpaired moves are forgotten
+	for i := uint32(10); i < 10+maxPendingMoves; i++ {
+		p.from(i, "/a/x")
+	}
+	if _, ok := p.to(2, "/c/gone"); ok {
+		t.Error("Expected oldest pending move to be dropped")
+	}
+	if len(p.pending) != maxPendingMoves || len(p.order) != maxPendingMoves {
+		t.Errorf("Expected %d pending moves, got %d/%d", maxPendingMoves, len(p.pending), len(p.order))
+	}
+}
+
+func TestRewritePath(t *testing.T) {
+	rename := Rename{From: "/home/user/code", To: "/home/user/work"}
+
+	tests := []struct {
+		path     string
+		expected string
+		moved    bool
+	}{
+		{"/home/user/code", "/home/user/work", true},
+		{"/home/user/code/api", "/home/user/work/api", true},
+		{"/home/user/codebase", "/home/user/codebase", false},
+		{"/home/user", "/home/user", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, moved := rewritePath(tt.path, rename)
+			if path != tt.expected || moved != tt.moved {
+				t.Errorf("rewritePath(%q) = %q, %v; expected %q, %v",
+					tt.path, path, moved, tt.expected, tt.moved)
+			}
+		})
+	}
+}
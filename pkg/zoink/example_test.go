@@ -0,0 +1,172 @@
+package zoink_test
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/iammatthew2/zoink/pkg/zoink"
+)
+
+// exampleDatabase returns the path of a database in a fresh directory and
+// a function removing it
+func exampleDatabase() (string, func()) {
+	dir, err := os.MkdirTemp("", "zoink-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return filepath.Join(dir, "zoink.db"), func() { os.RemoveAll(dir) }
+}
+
+func Example() {
+	path, cleanup := exampleDatabase()
+	defer cleanup()
+
+	db, err := zoink.Open(path, zoink.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	db.Add("/home/user/src/zoink")
+	db.Add("/home/user/src/zoink")
+	db.Add("/home/user/notes")
+
+	results, err := db.Search("zk", zoink.SearchOptions{MaxResults: 1})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, result := range results {
+		fmt.Println(result.Path, result.Visits)
+	}
+	// Output: /home/user/src/zoink 2
+}
+
+func ExampleOpenReadOnly() {
+	path, cleanup := exampleDatabase()
+	defer cleanup()
+
+	db, err := zoink.Open(path, zoink.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Add("/srv/www")
+	if err := db.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	readOnly, err := zoink.OpenReadOnly(path, zoink.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer readOnly.Close()
+
+	fmt.Println(readOnly.Len())
+	fmt.Println(readOnly.Add("/tmp"))
+	// Output:
+	// 1
+	// database is read-only
+}
+
+func ExampleDB_Search() {
+	path, cleanup := exampleDatabase()
+	defer cleanup()
+
+	db, err := zoink.Open(path, zoink.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, dir := range []string{"/work/api-server", "/work/api-client", "/work/web"} {
+		db.Add(dir)
+	}
+
+	// fzf-style operators: names starting with "api" but not containing "client"
+	results, err := db.Search("^api !client", zoink.SearchOptions{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, result := range results {
+		fmt.Println(result.Path)
+	}
+	// Output: /work/api-server
+}
+
+func ExampleDB_SearchPaths() {
+	path, cleanup := exampleDatabase()
+	defer cleanup()
+
+	db, err := zoink.Open(path, zoink.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	db.Add("/work/client-a/frontend")
+	db.Add("/work/client-b/frontend")
+	db.Add("/home/user/frontend")
+
+	match, err := zoink.MatchGlob("/work/*/frontend")
+	if err != nil {
+		log.Fatal(err)
+	}
+	results, err := db.SearchPaths(match, zoink.SearchOptions{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, result := range results {
+		fmt.Println(result.Path)
+	}
+	// Output:
+	// /work/client-a/frontend
+	// /work/client-b/frontend
+}
+
+func ExampleDB_All() {
+	path, cleanup := exampleDatabase()
+	defer cleanup()
+
+	db, err := zoink.Open(path, zoink.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	db.Add("/a")
+	db.Add("/b")
+	db.Add("/b")
+
+	for entry := range db.All() {
+		fmt.Println(entry.Path, entry.Visits)
+	}
+	// Output:
+	// /b 2
+	// /a 1
+}
+
+func ExampleDB_Remove() {
+	path, cleanup := exampleDatabase()
+	defer cleanup()
+
+	db, err := zoink.Open(path, zoink.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	db.Add("/tmp/build")
+
+	removed, err := db.Remove("/tmp/build")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(removed)
+
+	_, tracked := db.Get("/tmp/build")
+	fmt.Println(tracked)
+	// Output:
+	// true
+	// false
+}
@@ -0,0 +1,111 @@
+package zoink
+
+import (
+	"time"
+
+	"github.com/iammatthew2/zoink/internal/database"
+)
+
+// Filter selects entries for Filter. Filters depending on the time, such as
+// OlderThan, are evaluated at the database clock.
+type Filter struct {
+	predicate func(now time.Time) database.Predicate
+}
+
+// filterOf wraps a predicate that doesn't depend on the time
+func filterOf(predicate database.Predicate) Filter {
+	return Filter{predicate: func(time.Time) database.Predicate { return predicate }}
+}
+
+// FilterFunc accepts the entries match returns true for
+func FilterFunc(match func(entry Entry) bool) Filter {
+	return Filter{predicate: func(now time.Time) database.Predicate {
+		return func(entry *database.DirectoryEntry) bool {
+			return match(newEntry(entry, now))
+		}
+	}}
+}
+
+// AllOf accepts entries accepted by every filter (every entry if none given)
+func AllOf(filters ...Filter) Filter {
+	return Filter{predicate: func(now time.Time) database.Predicate {
+		return database.All(predicates(filters, now)...)
+	}}
+}
+
+// AnyOf accepts entries accepted by at least one filter
+func AnyOf(filters ...Filter) Filter {
+	return Filter{predicate: func(now time.Time) database.Predicate {
+		return database.Any(predicates(filters, now)...)
+	}}
+}
+
+// predicates builds the predicates of filters at now
+func predicates(filters []Filter, now time.Time) []database.Predicate {
+	built := make([]database.Predicate, len(filters))
+	for i, filter := range filters {
+		built[i] = filter.predicate(now)
+	}
+	return built
+}
+
+// OlderThan accepts entries not visited within age
+func OlderThan(age time.Duration) Filter {
+	return Filter{predicate: func(now time.Time) database.Predicate {
+		return database.OlderThan(age, now)
+	}}
+}
+
+// FrecencyBelow accepts entries whose frecency is below x
+func FrecencyBelow(x float64) Filter {
+	return Filter{predicate: func(now time.Time) database.Predicate {
+		return database.FrecencyBelow(x, now)
+	}}
+}
+
+// VisitsBelow accepts entries visited fewer than n times
+func VisitsBelow(n int) Filter {
+	return filterOf(database.VisitsBelow(uint32(max(n, 0))))
+}
+
+// Under accepts entries at or below dir
+func Under(dir string) Filter {
+	return filterOf(database.Under(dir))
+}
+
+// Matching accepts entries whose path matches a glob. Patterns without a
+// separator are matched against the basename.
+func Matching(pattern string) (Filter, error) {
+	predicate, err := database.Matching(pattern)
+	if err != nil {
+		return Filter{}, err
+	}
+	return filterOf(predicate), nil
+}
+
+// Tagged accepts entries carrying a tag, with or without its leading '#'
+func Tagged(tag string) Filter {
+	return filterOf(database.Tagged(tag))
+}
+
+// Pinned accepts pinned entries
+func Pinned() Filter {
+	return filterOf(func(entry *database.DirectoryEntry) bool {
+		return entry.IsPinned()
+	})
+}
+
+// ParseAge parses ages such as "90m", "36h", "180d", "6w", "3mo" or "1y"
+func ParseAge(s string) (time.Duration, error) {
+	return database.ParseAge(s)
+}
+
+// Filter returns the entries accepted by filter, sorted by path
+func (d *DB) Filter(filter Filter) []Entry {
+	var entries []Entry
+	d.View(func(tx *Tx) error {
+		entries = tx.Filter(filter)
+		return nil
+	})
+	return entries
+}
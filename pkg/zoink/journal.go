@@ -0,0 +1,48 @@
+package zoink
+
+import (
+	"time"
+
+	"github.com/iammatthew2/zoink/internal/database"
+)
+
+// Operation is a change recorded by UpdateUndoable (or a zoink command such
+// as remove, clean, prune or mv) that Undo can revert
+type Operation struct {
+	Name string
+	Time time.Time
+	// Entries is how many entries undoing it reverts; a move counts both
+	// the old and the new path of each entry
+	Entries int
+}
+
+// LastOperation returns the operation Undo would revert, or nil
+func (d *DB) LastOperation() (*Operation, error) {
+	operation, err := d.db.LastOperation()
+	if err != nil || operation == nil {
+		return nil, err
+	}
+	return newOperation(operation), nil
+}
+
+// Undo reverts the most recent recorded operation and saves the database.
+// Entries changed since are reset to their state from before it.
+func (d *DB) Undo() (*Operation, error) {
+	if d.readOnly {
+		return nil, ErrReadOnly
+	}
+	operation, err := d.db.Undo()
+	if err != nil {
+		return nil, err
+	}
+	return newOperation(operation), nil
+}
+
+// newOperation converts a journal operation
+func newOperation(operation *database.JournalOperation) *Operation {
+	return &Operation{
+		Name:    operation.Operation,
+		Time:    time.Unix(operation.Time, 0),
+		Entries: len(operation.Changes),
+	}
+}
@@ -0,0 +1,133 @@
+package zoink
+
+import (
+	"time"
+
+	"github.com/iammatthew2/zoink/internal/database"
+)
+
+// PathStatus tells whether an entry's directory still exists
+type PathStatus = database.PathStatus
+
+const (
+	// PathPresent means the directory exists
+	PathPresent = database.PathPresent
+	// PathMissing means the directory was deleted
+	PathMissing = database.PathMissing
+	// PathOffline means the directory lives on a volume that isn't mounted
+	PathOffline = database.PathOffline
+)
+
+// ImportMarker records how far a shell history file was imported
+type ImportMarker = database.ImportMarker
+
+// Tx is a transaction passed to View and Update. Entries it returns are
+// copies. A Tx must not be used after its function returns.
+type Tx struct {
+	tx  *database.Tx
+	now time.Time
+}
+
+// View runs fn in a read-only transaction over a consistent view of the
+// database
+func (d *DB) View(fn func(tx *Tx) error) error {
+	return d.db.View(func(tx *database.Tx) error {
+		return fn(&Tx{tx: tx, now: d.clock()})
+	})
+}
+
+// Update runs fn in a read-write transaction while holding the exclusive
+// database lock. The database is reloaded first, so fn sees changes saved by
+// other processes and unsaved changes made outside the transaction are
+// dropped. If fn returns an error nothing it changed is kept; otherwise
+// everything is saved in one write.
+func (d *DB) Update(fn func(tx *Tx) error) error {
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	return d.db.Update(func(tx *database.Tx) error {
+		return fn(&Tx{tx: tx, now: d.clock()})
+	})
+}
+
+// UpdateUndoable is Update recording the entries fn removes or rewrites as
+// operation, so Undo (or 'zoink undo') can revert them
+func (d *DB) UpdateUndoable(operation string, fn func(tx *Tx) error) error {
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	d.db.StartJournal(operation)
+	return d.Update(fn)
+}
+
+// Get returns the entry for a directory
+func (tx *Tx) Get(path string) (Entry, bool) {
+	entry, exists := tx.tx.Get(path)
+	if !exists {
+		return Entry{}, false
+	}
+	return newEntry(entry, tx.now), true
+}
+
+// All returns every entry, pinned entries first, then by frecency
+func (tx *Tx) All() []Entry {
+	return tx.entries(tx.tx.All())
+}
+
+// Filter returns the entries accepted by filter, sorted by path
+func (tx *Tx) Filter(filter Filter) []Entry {
+	return tx.entries(tx.tx.Filter(filter.predicate(tx.now)))
+}
+
+// CheckPath reports whether an entry's directory still exists
+func (tx *Tx) CheckPath(path string) PathStatus {
+	return tx.tx.CheckPath(path)
+}
+
+// Add records a visit to a directory
+func (tx *Tx) Add(path string) error {
+	return tx.tx.AddVisit(path)
+}
+
+// AddAt records a visit made at a given time, such as one imported from
+// shell history. Visits before the entry's last one only extend its history.
+func (tx *Tx) AddAt(path string, visited time.Time) error {
+	return tx.tx.AddVisitAt(path, visited.Unix())
+}
+
+// Remove forgets a directory and reports whether it was tracked
+func (tx *Tx) Remove(path string) (bool, error) {
+	return tx.tx.Remove(path)
+}
+
+// Move rewrites every entry at or below oldPrefix to newPrefix, merging
+// entries that already exist there. It returns how many entries moved and
+// how many of those were merged.
+func (tx *Tx) Move(oldPrefix, newPrefix string) (int, int, error) {
+	return tx.tx.MovePrefix(oldPrefix, newPrefix)
+}
+
+// SetOffline marks a directory as living on a volume that isn't mounted
+func (tx *Tx) SetOffline(path string, offline bool) error {
+	return tx.tx.SetOffline(path, offline)
+}
+
+// ImportMarker returns how far a history file was imported
+func (tx *Tx) ImportMarker(source string) (ImportMarker, bool) {
+	return tx.tx.ImportMarker(source)
+}
+
+// SetImportMarker saves how far a history file was imported along with the
+// visits imported from it
+func (tx *Tx) SetImportMarker(source string, marker ImportMarker) error {
+	return tx.tx.SetImportMarker(source, marker)
+}
+
+// entries converts internal entries
+func (tx *Tx) entries(entries []*database.DirectoryEntry) []Entry {
+	converted := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		converted = append(converted, newEntry(entry, tx.now))
+	}
+	return converted
+}
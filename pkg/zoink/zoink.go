@@ -0,0 +1,420 @@
+// Package zoink opens, searches and updates the zoink directory database
+// from other Go programs. It is the stable API the zoink command line tool
+// is built on; everything under internal/ may change without notice.
+//
+// A DB is safe for concurrent use. Changes are kept in memory until Save or
+// Close writes them, under a file lock shared with other zoink processes.
+package zoink
+
+import (
+	"iter"
+	"time"
+
+	"github.com/iammatthew2/zoink/internal/config"
+	"github.com/iammatthew2/zoink/internal/database"
+)
+
+// Errors returned by Open and DB methods, to be checked with errors.Is
+var (
+	// ErrReadOnly means a database opened with OpenReadOnly was changed
+	ErrReadOnly = database.ErrReadOnly
+	// ErrNotFound means a directory isn't in the database
+	ErrNotFound = database.ErrNotFound
+	// ErrCorrupt means the database file can't be parsed
+	ErrCorrupt = database.ErrCorrupt
+	// ErrVersionMismatch means the database file was written by a newer
+	// version of zoink
+	ErrVersionMismatch = database.ErrVersionMismatch
+	// ErrLocked means another process held the database lock too long
+	ErrLocked = database.ErrLocked
+)
+
+// Options configure how a database is opened
+type Options struct {
+	// MaxEntries caps the number of entries; the lowest-ranked entries
+	// beyond it are evicted on save (0 = unlimited)
+	MaxEntries int
+	// Clock returns the current time (default time.Now)
+	Clock func() time.Time
+	// LockTimeout bounds waiting for another process holding the database
+	// lock; Open and Save then fail with ErrLocked (default 10s)
+	LockTimeout time.Duration
+}
+
+// SearchOptions tune how Search selects and ranks entries
+type SearchOptions struct {
+	// MaxResults limits the number of results (0 = unlimited)
+	MaxResults int
+	// IncludeOffline also returns entries on volumes that aren't mounted
+	IncludeOffline bool
+	// MatchNotes lets query words that don't match a path match its note
+	MatchNotes bool
+	// From is the current directory; entries often visited next from it
+	// are ranked higher
+	From string
+	// TimeAware ranks entries usually visited at the current hour of the
+	// week higher
+	TimeAware bool
+}
+
+// Entry is a copy of a directory's record. Changing it doesn't change the
+// database.
+type Entry struct {
+	Path         string
+	Visits       int
+	FirstVisited time.Time
+	LastVisited  time.Time
+	// Frecency is the visit count decayed by the time since the last visit
+	Frecency float64
+	Pinned   bool
+	// Offline entries are on volumes that weren't mounted when last checked
+	Offline bool
+	Tags    []string
+	Note    string
+}
+
+// Result is an entry found by a search with the scores it was ranked by
+type Result struct {
+	Entry
+	// Score is the final ranking score: the match quality combined with
+	// frecency and any learned boosts
+	Score float64
+	// MatchScore is how well the query matched the path (0 for searches
+	// without a query)
+	MatchScore int
+}
+
+// DB is an open zoink database
+type DB struct {
+	db       *database.Database
+	readOnly bool
+	clock    func() time.Time
+}
+
+// DefaultPath returns the location of the database used by the zoink
+// command, honoring database_path from its config file
+func DefaultPath() (string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+	return cfg.DatabasePath, nil
+}
+
+// Open opens the database at path for reading and writing, creating it on
+// the first save if it doesn't exist yet
+func Open(path string, opts Options) (*DB, error) {
+	return open(path, opts, false)
+}
+
+// OpenReadOnly opens the database at path without ever writing to it. A
+// missing database opens empty.
+func OpenReadOnly(path string, opts Options) (*DB, error) {
+	return open(path, opts, true)
+}
+
+// open opens a database in either mode
+func open(path string, opts Options, readOnly bool) (*DB, error) {
+	db, err := database.New(database.DatabaseConfig{
+		Path:        path,
+		MaxEntries:  opts.MaxEntries,
+		Clock:       opts.Clock,
+		ReadOnly:    readOnly,
+		LockTimeout: opts.LockTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	clock := opts.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	return &DB{db: db, readOnly: readOnly, clock: clock}, nil
+}
+
+// QueueVisit records a visit to dir, coming from the directory from if it
+// isn't empty, in the database at path without waiting for its lock. The
+// visit is dated by opts.Clock and the next successful save by any process
+// adds it. Use it when Open or Save fails with ErrLocked and the visit
+// mustn't be lost.
+func QueueVisit(path, dir, from string, opts Options) error {
+	clock := opts.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	return database.SpoolVisit(path, dir, from, clock())
+}
+
+// Close saves any changes. Read-only databases are left untouched.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Save writes the changes made so far
+func (d *DB) Save() error {
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	return d.db.Save()
+}
+
+// Search ranks the entries matching a query, best first. The query uses the
+// same syntax as 'zoink find': fuzzy terms matched against basenames,
+// fzf-style operators ('exact, ^prefix, suffix$, !not, a|b) and #tag
+// filters. An empty query ranks every entry by frecency.
+func (d *DB) Search(query string, opts SearchOptions) ([]Result, error) {
+	matches, err := d.db.QueryScored(query, database.QueryOptions{
+		MaxResults:     opts.MaxResults,
+		IncludeOffline: opts.IncludeOffline,
+		MatchNotes:     opts.MatchNotes,
+		From:           opts.From,
+		TimeAware:      opts.TimeAware,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.results(matches), nil
+}
+
+// SearchPaths ranks the entries whose full path is accepted by match, such
+// as one returned by MatchRegexp or MatchGlob, by frecency. match may be
+// called concurrently.
+func (d *DB) SearchPaths(match func(path string) bool, opts SearchOptions) ([]Result, error) {
+	matches, err := d.db.QueryMatchingScored(func(entry *database.DirectoryEntry) bool {
+		return match(entry.Path)
+	}, database.QueryOptions{
+		MaxResults:     opts.MaxResults,
+		IncludeOffline: opts.IncludeOffline,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.results(matches), nil
+}
+
+// MatchRegexp returns a matcher for SearchPaths accepting paths that match
+// a regular expression anywhere
+func MatchRegexp(pattern string) (func(path string) bool, error) {
+	return pathMatcher(database.MatchingRegexp(pattern))
+}
+
+// MatchGlob returns a matcher for SearchPaths accepting whole paths that
+// match a glob. '*' and '?' don't match '/', '**' does.
+func MatchGlob(pattern string) (func(path string) bool, error) {
+	return pathMatcher(database.MatchingPathGlob(pattern))
+}
+
+// pathMatcher adapts a path predicate to a plain path matcher
+func pathMatcher(predicate database.Predicate, err error) (func(path string) bool, error) {
+	if err != nil {
+		return nil, err
+	}
+	return func(path string) bool {
+		return predicate(&database.DirectoryEntry{Path: path})
+	}, nil
+}
+
+// Get returns the entry for a directory
+func (d *DB) Get(path string) (Entry, bool) {
+	entry, exists := d.db.Get(path)
+	if !exists {
+		return Entry{}, false
+	}
+	return newEntry(&entry, d.clock()), true
+}
+
+// All iterates over every entry, pinned entries first, then by frecency
+func (d *DB) All() iter.Seq[Entry] {
+	return func(yield func(Entry) bool) {
+		entries, _ := d.db.GetAll()
+		now := d.clock()
+		for _, entry := range entries {
+			if !yield(newEntry(entry, now)) {
+				return
+			}
+		}
+	}
+}
+
+// CheckPath reports whether an entry's directory still exists, telling a
+// deleted directory apart from one on a volume that isn't mounted
+func (d *DB) CheckPath(path string) PathStatus {
+	return d.db.CheckPath(path)
+}
+
+// Len returns the number of entries
+func (d *DB) Len() int {
+	return d.db.Len()
+}
+
+// Add records a visit to a directory
+func (d *DB) Add(path string) error {
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	return d.db.AddVisit(path)
+}
+
+// AddFrom records a visit to a directory coming from another one, which
+// teaches the ranking where users usually go next
+func (d *DB) AddFrom(path, from string) error {
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	if from == "" {
+		return d.db.AddVisit(path)
+	}
+	return d.db.AddVisit(path, from)
+}
+
+// Remove forgets a directory and reports whether it was tracked. Removals
+// are recorded like those of 'zoink remove', so after saving they can be
+// reverted with 'zoink undo'.
+func (d *DB) Remove(path string) (bool, error) {
+	if d.readOnly {
+		return false, ErrReadOnly
+	}
+	if _, exists := d.db.Get(path); !exists {
+		return false, nil
+	}
+	d.db.ContinueJournal("remove")
+	if err := d.db.RemoveDirectory(path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RecordChoice records that the user picked path from several results for
+// query, so it ranks higher for similar queries
+func (d *DB) RecordChoice(query, path string) error {
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	return d.db.RecordChoice(query, path)
+}
+
+// AddScanned adds a directory without counting a visit, as 'zoink scan'
+// does, and reports whether it was new
+func (d *DB) AddScanned(path string) (bool, error) {
+	if d.readOnly {
+		return false, ErrReadOnly
+	}
+	return d.db.AddScanned(path)
+}
+
+// SetPinned pins a directory to the top of results or unpins it
+func (d *DB) SetPinned(path string, pinned bool) error {
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	return d.db.SetPinned(path, pinned)
+}
+
+// SetNote attaches a note to a directory; an empty note clears it
+func (d *DB) SetNote(path, note string) error {
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	return d.db.SetNote(path, note)
+}
+
+// AddTags tags a directory. Tags are normalized to a leading '#'.
+func (d *DB) AddTags(path string, tags ...string) error {
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	return d.db.AddTags(path, tags...)
+}
+
+// RemoveTags removes tags from a directory and returns how many it had
+func (d *DB) RemoveTags(path string, tags ...string) (int, error) {
+	if d.readOnly {
+		return 0, ErrReadOnly
+	}
+	return d.db.RemoveTags(path, tags...)
+}
+
+// TagCounts returns how many entries carry each tag
+func (d *DB) TagCounts() map[string]int {
+	return d.db.TagCounts()
+}
+
+// ForgetChoices forgets the choices recorded for query, or all of them if
+// it is empty, and returns how many were forgotten
+func (d *DB) ForgetChoices(query string) (int, error) {
+	if d.readOnly {
+		return 0, ErrReadOnly
+	}
+	return d.db.ForgetChoices(query), nil
+}
+
+// Prediction is a directory likely to be visited next and the share of
+// recorded transitions leading to it
+type Prediction struct {
+	Entry
+	Share float64
+}
+
+// Next predicts the directories most often visited next from the directory
+// from, most likely first
+func (d *DB) Next(from string, maxResults int) ([]Prediction, error) {
+	predictions, err := d.db.Next(from, maxResults)
+	if err != nil {
+		return nil, err
+	}
+	now := d.clock()
+	converted := make([]Prediction, 0, len(predictions))
+	for _, prediction := range predictions {
+		converted = append(converted, Prediction{
+			Entry: newEntry(prediction.Entry, now),
+			Share: prediction.Share,
+		})
+	}
+	return converted, nil
+}
+
+// EvictedCount returns how many entries were evicted to stay within
+// MaxEntries since the database was created
+func (d *DB) EvictedCount() uint64 {
+	return d.db.EvictedCount()
+}
+
+// Reload rereads the database file, dropping unsaved changes
+func (d *DB) Reload() error {
+	return d.db.Reload()
+}
+
+// PreviousDir returns the directory the zoink command last recorded a visit
+// from, used by 'zoink back'
+func PreviousDir() (string, error) {
+	return database.GetPreviousPath()
+}
+
+// newEntry copies an internal entry, scoring its frecency at now
+func newEntry(entry *database.DirectoryEntry, now time.Time) Entry {
+	return Entry{
+		Path:         entry.Path,
+		Visits:       int(entry.VisitCount),
+		FirstVisited: time.Unix(entry.FirstVisited, 0),
+		LastVisited:  time.Unix(entry.LastVisited, 0),
+		Frecency:     entry.FrecencyAt(now),
+		Pinned:       entry.IsPinned(),
+		Offline:      entry.IsOffline(),
+		Tags:         append([]string(nil), entry.Tags...),
+		Note:         entry.Note,
+	}
+}
+
+// results converts ranked matches
+func (d *DB) results(matches []database.MatchResult) []Result {
+	now := d.clock()
+	converted := make([]Result, 0, len(matches))
+	for _, match := range matches {
+		converted = append(converted, Result{
+			Entry:      newEntry(match.Entry, now),
+			Score:      match.CombinedScore,
+			MatchScore: match.FuzzyScore,
+		})
+	}
+	return converted
+}
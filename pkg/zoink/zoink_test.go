@@ -0,0 +1,160 @@
+package zoink
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenReadOnlyWritesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "zoink.db")
+
+	db, err := OpenReadOnly(path, Options{})
+	if err != nil {
+		t.Fatalf("OpenReadOnly failed: %v", err)
+	}
+	if db.Len() != 0 {
+		t.Errorf("missing database has %d entries, want 0", db.Len())
+	}
+	if err := db.Save(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Save = %v, want ErrReadOnly", err)
+	}
+	if _, err := db.Remove("/tmp"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Remove = %v, want ErrReadOnly", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Errorf("read-only open created the database directory")
+	}
+}
+
+func TestEntriesAreCopies(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "zoink.db"), Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	db.Add("/home/user/project")
+
+	results, err := db.Search("project", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].MatchScore <= 0 || results[0].Score <= 0 {
+		t.Fatalf("Search = %+v, want one scored result", results)
+	}
+
+	results[0].Path = "/changed"
+	if _, exists := db.Get("/home/user/project"); !exists {
+		t.Error("changing a result changed the database")
+	}
+}
+
+func TestQueueVisitUsesClock(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	opts := Options{Clock: func() time.Time { return now }}
+	path := filepath.Join(t.TempDir(), "zoink.db")
+
+	if err := QueueVisit(path, "/work/api", "/work", opts); err != nil {
+		t.Fatalf("QueueVisit failed: %v", err)
+	}
+
+	db, err := Open(path, opts)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := db.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	entry, exists := db.Get("/work/api")
+	if !exists || !entry.LastVisited.Equal(now) {
+		t.Errorf("Queued visit = %+v, want one at %s", entry, now)
+	}
+}
+
+func TestUpdateUndoableCanBeUndone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zoink.db")
+	db, err := Open(path, Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	db.Add("/work/api")
+	db.Add("/work/web")
+	if err := db.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	err = db.UpdateUndoable("mv", func(tx *Tx) error {
+		_, _, err := tx.Move("/work", "/src")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("UpdateUndoable failed: %v", err)
+	}
+	if _, exists := db.Get("/src/api"); !exists {
+		t.Fatal("Move didn't rewrite /work/api")
+	}
+
+	operation, err := db.Undo()
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if operation.Name != "mv" || operation.Entries != 4 {
+		t.Errorf("Undo = %+v, want mv reverting 4 entries", operation)
+	}
+	if _, exists := db.Get("/work/api"); !exists {
+		t.Error("Undo didn't restore /work/api")
+	}
+	if _, exists := db.Get("/src/api"); exists {
+		t.Error("Undo kept /src/api")
+	}
+
+	readOnly, err := OpenReadOnly(path, Options{})
+	if err != nil {
+		t.Fatalf("OpenReadOnly failed: %v", err)
+	}
+	if err := readOnly.Update(func(*Tx) error { return nil }); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Update = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestFiltersFollowClock(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	now := start
+	db, err := Open(filepath.Join(t.TempDir(), "zoink.db"), Options{
+		Clock: func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	db.Add("/work/api")
+	db.Add("/work/api")
+	db.Add("/work/web")
+	db.SetPinned("/work/web", true)
+
+	stale := OlderThan(30 * 24 * time.Hour)
+	if entries := db.Filter(stale); len(entries) != 0 {
+		t.Errorf("Filter(OlderThan) = %v right after the visits, want none", entries)
+	}
+	now = start.Add(60 * 24 * time.Hour)
+	if entries := db.Filter(stale); len(entries) != 2 {
+		t.Errorf("Filter(OlderThan) = %v 60 days later, want both entries", entries)
+	}
+
+	filter := AllOf(stale, VisitsBelow(2), FilterFunc(func(entry Entry) bool {
+		return entry.Pinned
+	}))
+	entries := db.Filter(filter)
+	if len(entries) != 1 || entries[0].Path != "/work/web" {
+		t.Errorf("Filter(AllOf) = %v, want /work/web", entries)
+	}
+}